@@ -0,0 +1,187 @@
+// Package db reads the symbols database written by inflate.go: one JSON
+// file per symbol, at <dir>/<path>.json for a path like
+// "appkit/nswindow".
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Symbol is the part of an inflated symbol the database answers queries
+// from. Paths are symbol paths, which may name symbols outside the
+// database.
+type Symbol struct {
+	Name         string
+	Path         string
+	Kind         string
+	Parent       string
+	InheritsFrom string
+	ParentPath   string `json:",omitempty"` // class or protocol owning a method or property
+	Required     bool   `json:",omitempty"` // required member of a protocol
+
+	ConformsTo       []string `json:",omitempty"` // protocols the symbol conforms to
+	InheritedBy      []string `json:",omitempty"` // subclasses, or protocols inheriting from a protocol
+	ConformingTypes  []string `json:",omitempty"` // types conforming to a protocol
+	InheritedByTypes []string `json:",omitempty"`
+
+	Topics          []TopicGroup `json:",omitempty"`
+	RequiredMembers []string     `json:",omitempty"` // of a protocol, as listed on its page
+}
+
+// TopicGroup is a task group of members, like "Creating a Window".
+type TopicGroup struct {
+	Title   string
+	Members []string // paths, in Apple's order
+}
+
+type DB struct {
+	dir string
+
+	mu       sync.Mutex
+	symbols  map[string]*Symbol
+	required map[string][]string // RequiredMembers, by protocol
+}
+
+// Open opens the database in dir, usually ./symbols.
+func Open(dir string) (*DB, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("db: %s is not a directory", dir)
+	}
+	return &DB{dir: dir, symbols: make(map[string]*Symbol), required: make(map[string][]string)}, nil
+}
+
+// Symbol returns the symbol at path. Symbols are read once and shared,
+// so callers must not modify them.
+func (db *DB) Symbol(path string) (*Symbol, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if s, ok := db.symbols[path]; ok {
+		return s, nil
+	}
+	b, err := ioutil.ReadFile(filepath.Join(db.dir, filepath.FromSlash(path)+".json"))
+	if err != nil {
+		return nil, err
+	}
+	s := &Symbol{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("db: %s: %w", path, err)
+	}
+	db.symbols[path] = s
+	return s, nil
+}
+
+// Has reports whether the database has a symbol at path.
+func (db *DB) Has(path string) bool {
+	_, err := os.Stat(filepath.Join(db.dir, filepath.FromSlash(path)+".json"))
+	return err == nil
+}
+
+// Conformances returns the paths of the protocols the class or protocol
+// at path conforms to, as documented on its page. Protocols conformed to
+// through a superclass are not included.
+func (db *DB) Conformances(path string) ([]string, error) {
+	s, err := db.Symbol(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.ConformsTo, nil
+}
+
+// Subclasses returns the paths of the direct subclasses of the class at
+// path, or of the protocols inheriting from the protocol at path.
+func (db *DB) Subclasses(path string) ([]string, error) {
+	s, err := db.Symbol(path)
+	if err != nil {
+		return nil, err
+	}
+	return union(s.InheritedBy, s.InheritedByTypes), nil
+}
+
+// ConformingTypes returns the paths of the types conforming to the
+// protocol at path, answering questions like which classes implement
+// NSCopying.
+func (db *DB) ConformingTypes(path string) ([]string, error) {
+	s, err := db.Symbol(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.ConformingTypes, nil
+}
+
+// Topics returns the task groups of the members of the symbol at path,
+// in the order Apple presents them.
+func (db *DB) Topics(path string) ([]TopicGroup, error) {
+	s, err := db.Symbol(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.Topics, nil
+}
+
+// RequiredMembers returns the paths of the methods and properties that
+// types conforming to the protocol at path must implement: those the
+// protocol's page lists as required, then members in its topics whose
+// own page says they are required. The list is built on the first call
+// for a protocol and shared, so callers must not modify it.
+func (db *DB) RequiredMembers(path string) ([]string, error) {
+	db.mu.Lock()
+	required, ok := db.required[path]
+	db.mu.Unlock()
+	if ok {
+		return required, nil
+	}
+
+	s, err := db.Symbol(path)
+	if err != nil {
+		return nil, err
+	}
+	var flagged []string
+	for _, group := range s.Topics {
+		for _, member := range group.Members {
+			m, err := db.Symbol(member)
+			if os.IsNotExist(err) {
+				// members can be outside the database
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if m.Required && m.ParentPath == path {
+				flagged = append(flagged, member)
+			}
+		}
+	}
+	required = union(s.RequiredMembers, flagged)
+
+	db.mu.Lock()
+	db.required[path] = required
+	db.mu.Unlock()
+	return required, nil
+}
+
+// union returns the paths in a then those only in b.
+func union(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, list := range [][]string{a, b} {
+		for _, path := range list {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
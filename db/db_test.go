@@ -0,0 +1,86 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRelationships(t *testing.T) {
+	db, err := Open("testdata/symbols")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query string
+		fn    func(path string) ([]string, error)
+		path  string
+		want  []string
+	}{
+		{"Conformances", db.Conformances, "foundation/nsstring", []string{"foundation/nscopying", "foundation/nsmutablecopying", "foundation/nssecurecoding"}},
+		{"Conformances", db.Conformances, "foundation/nsobject", []string{"objectivec/nsobjectprotocol"}},
+		{"Conformances", db.Conformances, "foundation/nscopying", nil},
+		// subclasses are listed in both sections, once each
+		{"Subclasses", db.Subclasses, "foundation/nsobject", []string{"appkit/nsresponder", "foundation/nsstring", "foundation/nsarray"}},
+		{"Subclasses", db.Subclasses, "appkit/nsresponder", []string{"appkit/nswindow"}},
+		{"Subclasses", db.Subclasses, "foundation/nsstring", nil},
+		{"ConformingTypes", db.ConformingTypes, "foundation/nscopying", []string{"foundation/nsstring", "foundation/nsarray"}},
+		{"ConformingTypes", db.ConformingTypes, "foundation/nsstring", nil},
+	}
+	for _, test := range tests {
+		got, err := test.fn(test.path)
+		if err != nil {
+			t.Errorf("%s(%s): %v", test.query, test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s(%s) = %q, want %q", test.query, test.path, got, test.want)
+		}
+	}
+
+	// symbols outside the database are errors, not empty answers
+	if _, err := db.Conformances("foundation/nscoding"); err == nil {
+		t.Errorf("Conformances of a missing symbol: no error")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := Open("testdata/missing"); err == nil {
+		t.Errorf("Open of a missing directory: no error")
+	}
+	if _, err := Open("testdata/symbols/foundation/nsobject.json"); err == nil {
+		t.Errorf("Open of a file: no error")
+	}
+}
+
+func TestRequiredMembers(t *testing.T) {
+	db, err := Open("testdata/symbols")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// listed as required first, then flagged on their own pages; not the
+	// optional ones, those of other protocols or those not in the database
+	want := []string{"appkit/nswindowdelegate/windowdidresize", "appkit/nswindowdelegate/windowshouldclose"}
+	got, err := db.RequiredMembers("appkit/nswindowdelegate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RequiredMembers(appkit/nswindowdelegate) = %q, want %q", got, want)
+	}
+	again, err := db.RequiredMembers("appkit/nswindowdelegate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != len(got) || &again[0] != &got[0] {
+		t.Errorf("RequiredMembers(appkit/nswindowdelegate) was built again")
+	}
+
+	got, err = db.RequiredMembers("foundation/nscopying")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"foundation/nscopying/copywithzone"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RequiredMembers(foundation/nscopying) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,326 @@
+// Package docc models DocC render JSON, the format of the documentation
+// pages under developer.apple.com/tutorials/data and in the
+// data/documentation directory of a .doccarchive.
+package docc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SupportedSchemaMajor is the render JSON schema major version this
+// package understands.
+const SupportedSchemaMajor = 0
+
+// RenderNode is a DocC page.
+type RenderNode struct {
+	SchemaVersion          SchemaVersion          `json:"schemaVersion"`
+	Kind                   string                 `json:"kind"`
+	Identifier             Identifier             `json:"identifier"`
+	Metadata               Metadata               `json:"metadata"`
+	Abstract               []InlineContent        `json:"abstract"`
+	DeprecationSummary     []BlockContent         `json:"deprecationSummary"`
+	PrimaryContentSections []ContentSection       `json:"primaryContentSections"`
+	TopicSections          []TopicSection         `json:"topicSections"`
+	RelationshipsSections  []RelationshipsSection `json:"relationshipsSections"`
+	SeeAlsoSections        []TopicSection         `json:"seeAlsoSections"`
+	References             map[string]Reference   `json:"references"`
+	Variants               []Variant              `json:"variants"`
+}
+
+type SchemaVersion struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+type Identifier struct {
+	URL               string `json:"url"`
+	InterfaceLanguage string `json:"interfaceLanguage"`
+}
+
+type Metadata struct {
+	Title          string     `json:"title"`
+	Role           string     `json:"role"`
+	RoleHeading    string     `json:"roleHeading"`
+	SymbolKind     string     `json:"symbolKind"`
+	ExternalID     string     `json:"externalID"`
+	Modules        []Module   `json:"modules"`
+	Platforms      []Platform `json:"platforms"`
+	Parent         *Parent    `json:"parent"`
+	Fragments      []Token    `json:"fragments"`
+	NavigatorTitle []Token    `json:"navigatorTitle"`
+	Required       bool       `json:"required"`
+}
+
+type Module struct {
+	Name string `json:"name"`
+}
+
+type Platform struct {
+	Name         string `json:"name"`
+	IntroducedAt string `json:"introducedAt"`
+	Current      string `json:"current"`
+	Beta         bool   `json:"beta"`
+	Deprecated   bool   `json:"deprecated"`
+	DeprecatedAt string `json:"deprecatedAt"`
+	Unavailable  bool   `json:"unavailable"`
+}
+
+type Parent struct {
+	Title string `json:"title"`
+}
+
+// ContentSection is an entry of primaryContentSections. Which fields are
+// set depends on Kind: "declarations", "parameters", "content",
+// "properties", "mentions" and so on.
+type ContentSection struct {
+	Kind         string             `json:"kind"`
+	Declarations []Declaration      `json:"declarations"`
+	Parameters   []ParameterSection `json:"parameters"`
+	Content      []BlockContent     `json:"content"`
+}
+
+type Declaration struct {
+	Tokens    []Token  `json:"tokens"`
+	Languages []string `json:"languages"`
+	Platforms []string `json:"platforms"`
+}
+
+// Token is a declaration token. Kind is one of "keyword", "identifier",
+// "typeIdentifier", "genericParameter", "externalParam",
+// "internalParam", "attribute", "number", "string", "label" or "text".
+type Token struct {
+	Kind              string `json:"kind"`
+	Text              string `json:"text"`
+	Identifier        string `json:"identifier"`
+	PreciseIdentifier string `json:"preciseIdentifier"`
+}
+
+type ParameterSection struct {
+	Name    string         `json:"name"`
+	Content []BlockContent `json:"content"`
+}
+
+// BlockContent is a block of rich content. Which fields are set depends
+// on Type: "paragraph", "heading", "aside", "unorderedList",
+// "orderedList", "termList", "table", "codeListing" and so on.
+type BlockContent struct {
+	Type          string             `json:"type"`
+	InlineContent []InlineContent    `json:"inlineContent"` // paragraph
+	Text          string             `json:"text"`          // heading
+	Level         int                `json:"level"`         // heading
+	Anchor        string             `json:"anchor"`        // heading
+	Style         string             `json:"style"`         // aside
+	Name          string             `json:"name"`          // aside
+	Content       []BlockContent     `json:"content"`       // aside
+	Items         []ListItem         `json:"items"`         // lists
+	Links         []string           `json:"-"`             // links: reference identifiers, from items
+	Start         int                `json:"start"`         // orderedList
+	Header        string             `json:"header"`        // table: "row", "column", "both" or ""
+	Rows          [][][]BlockContent `json:"rows"`          // table rows of cells
+	Syntax        string             `json:"syntax"`        // codeListing
+	Code          []string           `json:"code"`          // codeListing lines
+}
+
+// UnmarshalJSON decodes items by the block type: a "links" block lists
+// reference identifiers rather than list items.
+func (b *BlockContent) UnmarshalJSON(data []byte) error {
+	type plain BlockContent
+	var v struct {
+		plain
+		Items json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*b = BlockContent(v.plain)
+	if len(v.Items) == 0 || string(v.Items) == "null" {
+		return nil
+	}
+	if b.Type == "links" {
+		return json.Unmarshal(v.Items, &b.Links)
+	}
+	return json.Unmarshal(v.Items, &b.Items)
+}
+
+type ListItem struct {
+	Content    []BlockContent `json:"content"`
+	Term       *TermContent   `json:"term"`       // termList
+	Definition *TermContent   `json:"definition"` // termList
+}
+
+type TermContent struct {
+	InlineContent []InlineContent `json:"inlineContent"`
+	Content       []BlockContent  `json:"content"`
+}
+
+// InlineContent is a run of inline content. Which fields are set depends
+// on Type: "text", "codeVoice", "emphasis", "strong", "newTerm",
+// "inlineHead", "superscript", "subscript", "strikethrough", "reference",
+// "link" or "image".
+type InlineContent struct {
+	Type          string          `json:"type"`
+	Text          string          `json:"text"`
+	Code          string          `json:"code"`
+	InlineContent []InlineContent `json:"inlineContent"`
+	Identifier    string          `json:"identifier"`
+	IsActive      bool            `json:"isActive"`
+	Destination   string          `json:"destination"`
+	Title         string          `json:"title"`
+}
+
+// TopicSection is a task group of members, like "Creating a Window".
+type TopicSection struct {
+	Title       string   `json:"title"`
+	Anchor      string   `json:"anchor"`
+	Identifiers []string `json:"identifiers"`
+}
+
+// RelationshipsSection lists related symbols. Type is one of
+// "inheritsFrom", "inheritedBy", "conformsTo", "conformingTypes" and so on.
+type RelationshipsSection struct {
+	Type        string   `json:"type"`
+	Kind        string   `json:"kind"`
+	Title       string   `json:"title"`
+	Identifiers []string `json:"identifiers"`
+}
+
+// Reference is an entry of the page's references map, describing a page
+// or asset the page links to by identifier.
+type Reference struct {
+	Type           string          `json:"type"`
+	Identifier     string          `json:"identifier"`
+	Kind           string          `json:"kind"`
+	Role           string          `json:"role"`
+	Title          string          `json:"title"`
+	URL            string          `json:"url"`
+	Abstract       []InlineContent `json:"abstract"`
+	Fragments      []Token         `json:"fragments"`
+	NavigatorTitle []Token         `json:"navigatorTitle"`
+	Required       bool            `json:"required"`
+	Deprecated     bool            `json:"deprecated"`
+}
+
+// Variant points at the same page in another interface language.
+type Variant struct {
+	Paths  []string `json:"paths"`
+	Traits []Trait  `json:"traits"`
+}
+
+type Trait struct {
+	InterfaceLanguage string `json:"interfaceLanguage"`
+}
+
+// Decode parses a render JSON page. Errors name the offending field, so
+// schema changes upstream show up as clear decode failures.
+func Decode(data []byte) (*RenderNode, error) {
+	var n RenderNode
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("docc: %w", err)
+	}
+	if n.SchemaVersion.Major != SupportedSchemaMajor {
+		return nil, fmt.Errorf("docc: unsupported schema version %d.%d.%d",
+			n.SchemaVersion.Major, n.SchemaVersion.Minor, n.SchemaVersion.Patch)
+	}
+	return &n, nil
+}
+
+// Section returns the index and first primary content section of kind,
+// or -1 and nil.
+func (n *RenderNode) Section(kind string) (int, *ContentSection) {
+	for i := range n.PrimaryContentSections {
+		if n.PrimaryContentSections[i].Kind == kind {
+			return i, &n.PrimaryContentSections[i]
+		}
+	}
+	return -1, nil
+}
+
+// Relationships returns the relationships section of typ, or nil.
+func (n *RenderNode) Relationships(typ string) *RelationshipsSection {
+	for i := range n.RelationshipsSections {
+		if n.RelationshipsSections[i].Type == typ {
+			return &n.RelationshipsSections[i]
+		}
+	}
+	return nil
+}
+
+// PathFromURL returns the symbols database path for a documentation URL
+// or reference identifier, like "appkit/nswindow" for
+// "doc://com.apple.documentation/documentation/appkit/nswindow" or
+// "/documentation/appkit/nswindow". It returns "" for URLs outside
+// /documentation.
+func PathFromURL(url string) string {
+	idx := strings.Index(url, "/documentation/")
+	if idx < 0 {
+		return ""
+	}
+	path := url[idx+len("/documentation/"):]
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	return strings.ToLower(strings.TrimSuffix(path, "/"))
+}
+
+// SymbolKind returns the docSet kind (as used by load.go) for the page,
+// or "" if it is not a symbol or framework page.
+func (n *RenderNode) SymbolKind() string {
+	if n.Metadata.Role == "collection" && n.Metadata.SymbolKind == "" {
+		return "Framework"
+	}
+	switch n.Metadata.RoleHeading {
+	case "Framework":
+		return "Framework"
+	case "Class":
+		return "Class"
+	case "Protocol":
+		return "Protocol"
+	case "Instance Method", "Type Method", "Initializer", "Method":
+		return "Method"
+	case "Instance Property", "Type Property", "Property":
+		return "Property"
+	case "Structure":
+		return "Struct"
+	case "Union":
+		return "Union"
+	case "Enumeration":
+		return "Enum"
+	case "Enumeration Case", "Constant", "Global Variable", "Variable":
+		return "Constant"
+	case "Type Alias":
+		return "Type"
+	case "Function":
+		return "Function"
+	case "Macro":
+		return "Macro"
+	}
+	// older pages and some archives only have the symbol kind
+	switch n.Metadata.SymbolKind {
+	case "cl", "class":
+		return "Class"
+	case "intf", "protocol":
+		return "Protocol"
+	case "instm", "clm", "intfm", "intfcm", "init", "method", "type.method":
+		return "Method"
+	case "instp", "clp", "intfp", "property", "type.property":
+		return "Property"
+	case "struct":
+		return "Struct"
+	case "union", "uc":
+		return "Union"
+	case "enum":
+		return "Enum"
+	case "case", "econst", "enum.case", "data", "var", "const":
+		return "Constant"
+	case "tdef", "typealias":
+		return "Type"
+	case "func":
+		return "Function"
+	case "macro":
+		return "Macro"
+	}
+	return ""
+}
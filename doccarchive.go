@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mactypes/symbolsdb/docc"
+	"github.com/mactypes/symbolsdb/docstore"
+)
+
+type Symbol struct {
+	Name   string
+	Path   string
+	Kind   string
+	Source string `json:",omitempty"` // the archive, so fetch.go leaves the symbol alone
+}
+
+// Loads locally built .doccarchive bundles as a symbol source, so
+// frameworks not on developer.apple.com can go in the same database.
+// Each symbol page gets a stub in ./symbols, like load.go writes but
+// with the archive as its Source, and its render JSON goes into the store
+// where inflate.go picks it up. fetch.go skips stubs with a Source, since
+// their pages aren't on developer.apple.com. Pages for symbols that are
+// already there from another source are reported as conflicts and left
+// out.
+//
+//	go run doccarchive.go path/to/MyKit.doccarchive ...
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: go run doccarchive.go <archive.doccarchive> ...")
+	}
+
+	targetDir := "./symbols"
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := docstore.Open("./cache/store")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	loaded := 0
+	skipped := 0
+	conflicts := 0
+	for _, archive := range os.Args[1:] {
+		fmt.Printf("Loading %s...\n", archive)
+		source := filepath.Base(filepath.Clean(archive))
+		dataDir := filepath.Join(archive, "data", "documentation")
+		err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || filepath.Ext(path) != ".json" {
+				return nil
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			page, err := docc.Decode(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			kind := page.SymbolKind()
+			if kind == "" {
+				// articles, tutorials and topic collections
+				skipped++
+				return nil
+			}
+
+			rel, err := filepath.Rel(dataDir, path)
+			if err != nil {
+				return err
+			}
+			s := Symbol{
+				Name:   page.Metadata.Title,
+				Path:   strings.ToLower(strings.TrimSuffix(filepath.ToSlash(rel), ".json")),
+				Kind:   kind,
+				Source: source,
+			}
+			if s.Kind == "Constant" {
+				s.Name = strings.Split(s.Name, " = ")[0]
+			}
+
+			symfile := filepath.Join(targetDir, fmt.Sprintf("%s.json", s.Path))
+			// reloading an archive replaces its own stubs, but never a
+			// symbol from Apple's site or another archive
+			prev, err := readSymbol(symfile)
+			_, stored := store.Lookup(s.Path)
+			if (err == nil && prev.Source != source) || (err != nil && stored) {
+				fmt.Println("CONFLICT:", s.Path)
+				conflicts++
+				return nil
+			}
+			if err := os.MkdirAll(filepath.Dir(symfile), 0755); err != nil {
+				return err
+			}
+			if err := writeJSON(symfile, s); err != nil {
+				return err
+			}
+			if _, err := store.Put(s.Path, data, docstore.Entry{
+				FetchedAt: info.ModTime(),
+				Status:    200,
+			}); err != nil {
+				return err
+			}
+			loaded++
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Printf("\nLoaded %d symbols, skipped %d other pages and %d conflicting symbols.\n", loaded, skipped, conflicts)
+}
+
+func readSymbol(filename string) (Symbol, error) {
+	var s Symbol
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
+
+func writeJSON(filepath string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath, b, 0644); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+// Package docstore is the on-disk cache of fetched DocC JSON pages.
+//
+// Pages are stored gzip compressed and addressed by the SHA-256 of their
+// uncompressed content, so identical pages are only kept once. An
+// append-only index maps symbol paths (like "appkit/nswindow") to content
+// hashes along with the HTTP validators from the fetch that produced them.
+//
+// Layout:
+//
+//	<dir>/objects/ab/cdef0123...json.gz
+//	<dir>/index
+//	<dir>/lock
+//
+// One process at a time opens the store for writing, holding a lock on
+// the lock file. Readers open it read-only alongside, seeing the index as
+// it was when they opened it.
+package docstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrReadOnly is returned when writing to a store opened read-only.
+var ErrReadOnly = errors.New("docstore: store is read-only")
+
+// Entry is the index record for a path. The last entry written for a
+// path wins. Hash is empty if the path was fetched but never had content.
+type Entry struct {
+	Path         string
+	Hash         string `json:",omitempty"`
+	ETag         string `json:",omitempty"`
+	LastModified string `json:",omitempty"`
+	FetchedAt    time.Time
+	Status       int
+}
+
+type Store struct {
+	dir      string
+	readOnly bool
+	lock     *os.File
+
+	mu      sync.Mutex
+	index   map[string]Entry
+	log     *os.File
+	enc     *json.Encoder
+	records int
+	torn    bool // the index ends in a partly written record
+}
+
+// Open opens the store in dir, creating it if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "objects"), 0755); err != nil {
+		return nil, err
+	}
+	lock, err := os.OpenFile(filepath.Join(dir, "lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("docstore: %s is in use by another process: %w", dir, err)
+	}
+	s := &Store{
+		dir:   dir,
+		lock:  lock,
+		index: make(map[string]Entry),
+	}
+	if err := s.load(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	if s.torn {
+		// drop the partial record so new ones aren't appended to it
+		if err := s.compact(); err != nil {
+			lock.Close()
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(s.indexPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	s.log = f
+	s.enc = json.NewEncoder(f)
+	return s, nil
+}
+
+// OpenReadOnly opens the store in dir for reading, which is safe while
+// another process writes to it. Writes fail with ErrReadOnly.
+func OpenReadOnly(dir string) (*Store, error) {
+	s := &Store{
+		dir:      dir,
+		readOnly: true,
+		index:    make(map[string]Entry),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) load() error {
+	f, err := os.Open(s.indexPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	for {
+		var e Entry
+		if err := dec.Decode(&e); err != nil {
+			// EOF, or a torn last line from a crash
+			s.torn = err != io.EOF
+			break
+		}
+		s.index[e.Path] = e
+		s.records++
+	}
+	return nil
+}
+
+// Lookup returns the index entry for path.
+func (s *Store) Lookup(path string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.index[path]
+	return e, ok
+}
+
+// Has reports whether there is content stored for path.
+func (s *Store) Has(path string) bool {
+	e, ok := s.Lookup(path)
+	return ok && e.Hash != ""
+}
+
+// Get returns the uncompressed content stored for path. The error
+// satisfies os.IsNotExist if there is none.
+func (s *Store) Get(path string) ([]byte, error) {
+	e, ok := s.Lookup(path)
+	if !ok || e.Hash == "" {
+		return nil, &os.PathError{Op: "get", Path: path, Err: os.ErrNotExist}
+	}
+	f, err := os.Open(s.objectPath(e.Hash))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
+
+// Put stores data for path and records e (with its Path and Hash set) in
+// the index. It reports whether the content differs from what was stored
+// before.
+func (s *Store) Put(path string, data []byte, e Entry) (changed bool, err error) {
+	if s.readOnly {
+		return false, ErrReadOnly
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if err := s.writeObject(hash, data); err != nil {
+		return false, err
+	}
+
+	prev, _ := s.Lookup(path)
+	e.Path = path
+	e.Hash = hash
+	if err := s.append(e); err != nil {
+		return false, err
+	}
+	return prev.Hash != hash, nil
+}
+
+// Update records new fetch metadata for path, keeping its content.
+func (s *Store) Update(path string, e Entry) error {
+	prev, _ := s.Lookup(path)
+	e.Path = path
+	e.Hash = prev.Hash
+	return s.append(e)
+}
+
+// Paths returns all paths with stored content.
+func (s *Store) Paths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var paths []string
+	for p, e := range s.index {
+		if e.Hash != "" {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Import adds a directory tree of plain JSON files, such as the old
+// cache/meta layout, to the store. Paths are relative to dir without the
+// ".json" extension. The entry metadata comes from the JSON object in
+// <file>.info, with the ETag, LastModified, FetchedAt and Status of the
+// fetch, if present, and otherwise is the file's modification time and
+// status 200.
+func (s *Store) Import(dir string) (n int, err error) {
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(p) != ".json" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		path := strings.TrimSuffix(filepath.ToSlash(rel), ".json")
+		if s.Has(path) {
+			return nil
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		e := Entry{FetchedAt: info.ModTime(), Status: 200}
+		if b, err := ioutil.ReadFile(p + ".info"); err == nil {
+			if err := json.Unmarshal(b, &e); err != nil {
+				return fmt.Errorf("%s.info: %w", p, err)
+			}
+		}
+		if _, err := s.Put(path, data, e); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return
+}
+
+// Close flushes the index, compacting it first if most of its records
+// have been superseded, and releases the lock.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.readOnly {
+		return nil
+	}
+	defer s.lock.Close()
+	if err := s.log.Close(); err != nil {
+		return err
+	}
+	if s.records > 2*len(s.index) {
+		return s.compact()
+	}
+	return nil
+}
+
+func (s *Store) compact() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	paths := make([]string, 0, len(s.index))
+	for p := range s.index {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if err := enc.Encode(s.index[p]); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(s.indexPath(), buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	s.records = len(s.index)
+	return nil
+}
+
+func (s *Store) append(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if err := s.enc.Encode(e); err != nil {
+		return err
+	}
+	s.index[e.Path] = e
+	s.records++
+	return nil
+}
+
+func (s *Store) writeObject(hash string, data []byte) error {
+	p := s.objectPath(hash)
+	if _, err := os.Stat(p); err == nil {
+		// already stored
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(p, buf.Bytes(), 0644)
+}
+
+func (s *Store) indexPath() string {
+	return filepath.Join(s.dir, "index")
+}
+
+func (s *Store) objectPath(hash string) string {
+	return filepath.Join(s.dir, "objects", hash[:2], hash[2:]+".json.gz")
+}
+
+// writeFileAtomic writes data to a temp file next to filename and renames
+// it into place, so an interrupted write never leaves a truncated file.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
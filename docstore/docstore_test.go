@@ -0,0 +1,189 @@
+package docstore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPut(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	page := []byte(`{"kind":"symbol"}`)
+	for _, test := range []struct {
+		path    string
+		data    []byte
+		changed bool
+	}{
+		{"appkit/nswindow", page, true},
+		{"appkit/nswindow", page, false},
+		{"appkit/nspanel", page, true},
+		{"appkit/nswindow", []byte(`{"kind":"article"}`), true},
+	} {
+		changed, err := s.Put(test.path, test.data, Entry{Status: 200})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != test.changed {
+			t.Errorf("Put(%q, %s) changed = %v, want %v", test.path, test.data, changed, test.changed)
+		}
+		data, err := s.Get(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, test.data) {
+			t.Errorf("Get(%q) = %s, want %s", test.path, data, test.data)
+		}
+	}
+
+	// identical pages share one object
+	objects, err := filepath.Glob(filepath.Join(dir, "objects", "*", "*.json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Errorf("stored %d objects, want 2", len(objects))
+	}
+	if got, want := s.Paths(), []string{"appkit/nspanel", "appkit/nswindow"}; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Paths() = %q, want %q", got, want)
+	}
+	if _, err := s.Get("appkit/nsview"); !os.IsNotExist(err) {
+		t.Errorf("Get of a missing path: got %v, want a not exist error", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if _, err := s.Put("appkit/nswindow", []byte(`{}`), Entry{ETag: `"1"`, Status: 200}); err != nil {
+		t.Fatal(err)
+	}
+	put, _ := s.Lookup("appkit/nswindow")
+	fetched := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := s.Update("appkit/nswindow", Entry{ETag: `"2"`, FetchedAt: fetched, Status: 304}); err != nil {
+		t.Fatal(err)
+	}
+	e, _ := s.Lookup("appkit/nswindow")
+	want := Entry{Path: "appkit/nswindow", Hash: put.Hash, ETag: `"2"`, FetchedAt: fetched, Status: 304}
+	if e != want {
+		t.Errorf("after Update, Lookup = %+v, want %+v", e, want)
+	}
+	if !s.Has("appkit/nswindow") {
+		t.Error("Update dropped the content")
+	}
+
+	// a path fetched without content has an entry but no content
+	if err := s.Update("appkit/nspanel", Entry{Status: 404}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Lookup("appkit/nspanel"); !ok || s.Has("appkit/nspanel") {
+		t.Errorf("after Update of a new path, Lookup ok = %v and Has = %v, want true and false", ok, s.Has("appkit/nspanel"))
+	}
+}
+
+func TestTornIndex(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put("appkit/nswindow", []byte(`{}`), Entry{Status: 200}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a crash partway through appending a record
+	f, err := os.OpenFile(filepath.Join(dir, "index"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"Path":"appkit/nspa`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	r, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Paths(); len(got) != 1 || got[0] != "appkit/nswindow" {
+		t.Errorf("Paths() = %q, want [appkit/nswindow]", got)
+	}
+	if _, err := r.Get("appkit/nswindow"); err != nil {
+		t.Error(err)
+	}
+	if _, err := r.Put("appkit/nspanel", []byte(`{}`), Entry{}); err != ErrReadOnly {
+		t.Errorf("Put on a read-only store: got %v, want ErrReadOnly", err)
+	}
+
+	// records written after reopening aren't lost to the torn one
+	s, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put("appkit/nspanel", []byte(`{}`), Entry{Status: 200}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if r, err = OpenReadOnly(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Paths(); len(got) != 2 {
+		t.Errorf("Paths() = %q, want [appkit/nspanel appkit/nswindow]", got)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put("appkit/nspanel", []byte(`{}`), Entry{Status: 200}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := s.Update("appkit/nswindow", Entry{Status: 304}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte("\n")); n != 2 {
+		t.Errorf("index has %d records after Close, want 2:\n%s", n, data)
+	}
+
+	s, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if e, ok := s.Lookup("appkit/nswindow"); !ok || e.Status != 304 {
+		t.Errorf("Lookup after compaction = %+v, %v", e, ok)
+	}
+	if !s.Has("appkit/nspanel") {
+		t.Error("compaction dropped appkit/nspanel")
+	}
+}
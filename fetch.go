@@ -4,117 +4,1022 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
+	"github.com/mactypes/symbolsdb/docc"
+	"github.com/mactypes/symbolsdb/docstore"
+	"github.com/mactypes/symbolsdb/warc"
 )
 
 type Symbol struct {
-	Name string
-	Path string
-	Kind string
-	File string
+	Name   string
+	Path   string
+	Kind   string
+	Source string `json:",omitempty"` // the .doccarchive the symbol was loaded from, if not Apple's site
+	File   string
 }
 
+// JournalEntry is one line of the fetch journal. The last entry for a
+// path is its current state: "started", "done" or "failed". The first
+// entry has no path but the arguments of the run, as Run.
+type JournalEntry struct {
+	Run   string `json:",omitempty"`
+	Path  string `json:",omitempty"`
+	State string `json:",omitempty"`
+	Error string `json:",omitempty"`
+	Time  time.Time
+}
+
+// Journal records per-symbol progress of a fetch run so an interrupted
+// run, or one with failures, can resume when run again with the same
+// arguments. It is removed once a run completes.
+type Journal struct {
+	mu    sync.Mutex
+	file  *os.File
+	enc   *json.Encoder
+	state map[string]string
+}
+
+// Stats summarizes a fetch run. It is printed at the end of the run and
+// saved as JSON, to spot upstream outages or framework-wide 404 waves.
+// Status counts are keyed by HTTP status, "error" for failed requests,
+// "cached" for pages skipped as fresh in the store and "skipped" for
+// pages on the known 404 list.
+type Stats struct {
+	mu sync.Mutex
+
+	Started  time.Time
+	Finished time.Time
+
+	Requests      int
+	Bytes         int64
+	AvgLatency    float64 // milliseconds
+	CacheHits     int     // fresh in the store, or not modified upstream
+	CacheMisses   int     // downloaded, missing or failed
+	CacheHitRatio float64
+	Resumed       int // done by an interrupted earlier run
+	Skipped       int // on the known 404 list
+
+	ByStatus    map[string]int
+	ByFramework map[string]map[string]int
+	ByKind      map[string]map[string]int
+
+	latency time.Duration
+}
+
+// Response is a fetched documentation page.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	Date   time.Time
+}
+
+// Fetcher fetches a documentation page, sending header with the request.
+type Fetcher interface {
+	Fetch(url string, header http.Header) (*Response, error)
+}
+
+// browserFetcher fetches through a remote Chrome, which gets past the
+// bot checks in front of the documentation JSON.
+type browserFetcher struct {
+	ctx context.Context
+
+	mu        sync.Mutex
+	requestID network.RequestID // of the last document response
+}
+
+// recordingFetcher archives every exchange of the wrapped Fetcher.
+type recordingFetcher struct {
+	Fetcher
+
+	mu sync.Mutex
+	w  *warc.Writer
+}
+
+// replayFetcher serves fetches from an archive without network access.
+type replayFetcher struct {
+	archive *warc.Archive
+}
+
+var (
+	errStopped     = errors.New("stopped")
+	errInterrupted = errors.New("interrupted")
+)
+
 func main() {
+	if err := run(); err != nil {
+		if err != errInterrupted {
+			log.Println(err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run does the work of main. It returns rather than exits on errors so
+// the store and journal are always closed.
+func run() (err error) {
+	refresh := flag.Bool("refresh", false, "re-fetch cached symbols using conditional requests")
+	maxAge := flag.Duration("max-age", 0, "with -refresh, skip symbols fetched more recently than this")
+	importDir := flag.String("import", "", "import a plain cache/meta style directory into the store and exit")
+	record := flag.String("record", "", "append every fetch exchange to this WARC file (.gz to compress)")
+	replay := flag.String("replay", "", "serve fetches from this WARC file instead of the network")
+	discover := flag.Bool("discover", false, "discover symbols by crawling DocC pages instead of walking ./symbols")
+	frameworks := flag.String("frameworks", "", "with -discover, comma separated frameworks to crawl (default all)")
+	depth := flag.Int("depth", 4, "with -discover, how many links to follow from the framework pages (0 for no limit)")
+	report := flag.String("report", "./cache/fetch-report.json", "write run statistics as JSON to this file")
+	flag.Parse()
 
 	known404, err := readFileLines("./404")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	targetDir := "./cache/meta"
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		log.Fatal(err)
-	}
-	toTargetPath := func(p string) string {
-		return strings.Replace(p, "symbols", targetDir, 1)
+	store, err := docstore.Open("./cache/store")
+	if err != nil {
+		return err
 	}
+	defer func() {
+		if cerr := store.Close(); err == nil {
+			err = cerr
+		}
+	}()
 
-	ctx := context.Background()
-	ctx, cancel := chromedp.NewRemoteAllocator(context.Background(), "http://localhost:9222/devtools/browser")
-	defer cancel()
-	ctx, cancel = chromedp.NewContext(ctx)
-	defer cancel()
+	if *importDir != "" {
+		n, err := store.Import(*importDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d files.\n", n)
+		return nil
+	}
 
-	ch := make(chan Symbol, 1024)
+	// a journal only resumes a run of the same mode and arguments
+	runArgs := fmt.Sprintf("refresh=%t max-age=%s discover=%t frameworks=%s depth=%d replay=%s",
+		*refresh, *maxAge, *discover, *frameworks, *depth, *replay)
+	journal, err := openJournal("./cache/fetch.journal", runArgs)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := journal.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	if n := journal.Count("done"); n > 0 {
+		fmt.Printf("Resuming, %d symbols already done...\n", n)
+	}
 
+	// stop is cancelled on SIGINT/SIGTERM. it only stops new work from
+	// being dispatched, the browser context stays alive so the symbol
+	// in flight can finish and be journaled. after the first signal the
+	// default handling is restored, so a second one kills a hung fetch.
+	stop, stopCancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopCancel()
 	go func() {
-		err := filepath.Walk("./symbols", func(path string, info os.FileInfo, err error) error {
+		<-stop.Done()
+		stopCancel()
+	}()
+
+	var fetcher Fetcher
+	if *replay != "" {
+		archive, err := warc.OpenArchive(*replay)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+		fmt.Printf("Replaying %d archived responses...\n", archive.Len())
+		fetcher = &replayFetcher{archive}
+	} else {
+		ctx, cancel := chromedp.NewRemoteAllocator(context.Background(), "http://localhost:9222/devtools/browser")
+		defer cancel()
+		ctx, cancel = chromedp.NewContext(ctx)
+		defer cancel()
+
+		// disable the browser cache so conditional requests reach upstream
+		// and a 304 is reported as such instead of being served from cache.
+		if err := chromedp.Run(ctx, network.Enable(), network.SetCacheDisabled(true)); err != nil {
+			return err
+		}
+		fetcher = newBrowserFetcher(ctx)
+	}
+	var recorder *recordingFetcher
+	if *record != "" {
+		f, err := os.OpenFile(*record, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w := warc.NewWriter(f, strings.HasSuffix(*record, ".gz"))
+		if err := w.WriteInfo(map[string]string{
+			"software": "symbolsdb fetch.go",
+			"format":   "WARC File Format 1.1",
+		}); err != nil {
+			return err
+		}
+		recorder = &recordingFetcher{Fetcher: fetcher, w: w}
+		fetcher = recorder
+	}
+
+	// archiveStored archives the stored page for path as if it had been
+	// fetched, so a recording covers pages this run didn't download and
+	// replay can rebuild everything from it.
+	archiveStored := func(path string) error {
+		if recorder == nil {
+			return nil
+		}
+		e, ok := store.Lookup(path)
+		if !ok || e.Hash == "" {
+			return nil
+		}
+		data, err := store.Get(path)
+		if err != nil {
+			return err
+		}
+		header := http.Header{}
+		if e.ETag != "" {
+			header.Set("ETag", e.ETag)
+		}
+		if e.LastModified != "" {
+			header.Set("Last-Modified", e.LastModified)
+		}
+		return recorder.archive(&warc.Exchange{
+			URI:            pageURL(path),
+			Date:           e.FetchedAt,
+			RequestHeader:  http.Header{},
+			Status:         http.StatusOK,
+			ResponseHeader: header,
+			Body:           data,
+		})
+	}
+
+	stats := newStats()
+
+	// needsFetch reports whether path, of kind if known, has to be
+	// (re)fetched in this run
+	needsFetch := func(path, kind string) (bool, error) {
+		if strIn(known404, path) {
+			stats.RecordSkipped(path, kind)
+			return false, nil
+		}
+		if journal.Done(path) {
+			stats.RecordResumed()
+			return false, archiveStored(path)
+		}
+		if e, ok := store.Lookup(path); ok && e.Hash != "" {
+			if !*refresh || (*maxAge > 0 && time.Since(e.FetchedAt) < *maxAge) {
+				if kind == "" {
+					kind = stubKind(path)
+				}
+				stats.RecordCached(path, kind)
+				return false, archiveStored(path)
+			}
+		}
+		return true, nil
+	}
+
+	// fetchPath fetches the page for path into the store, journaling
+	// its progress. kind is used for stats until the page says otherwise.
+	fetchPath := func(path, kind string) error {
+		if err := journal.Record(path, "started", nil); err != nil {
+			return err
+		}
+
+		// conditional request headers from the previous fetch, if any
+		header := http.Header{}
+		prev, cached := store.Lookup(path)
+		if cached && *refresh {
+			if prev.ETag != "" {
+				header.Set("If-None-Match", prev.ETag)
+			}
+			if prev.LastModified != "" {
+				header.Set("If-Modified-Since", prev.LastModified)
+			}
+		}
+
+		start := time.Now()
+		resp, err := fetcher.Fetch(pageURL(path), header)
+		if err != nil {
+			stats.Record(path, kind, 0, time.Since(start), 0)
+			fmt.Println(path, " => ", err)
+			// the cached page stays, so the recording must have it
+			if err := archiveStored(path); err != nil {
+				return err
+			}
+			return journal.Record(path, "failed", err)
+		}
+		latency := time.Since(start)
+
+		entry := docstore.Entry{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			FetchedAt:    resp.Date,
+			Status:       resp.Status,
+		}
+
+		switch resp.Status {
+		case http.StatusOK:
+			page, err := docc.Decode(resp.Body)
+			if err != nil {
+				// not stored, so inflate keeps the last good page
+				stats.Record(path, kind, 0, latency, len(resp.Body))
+				fmt.Println(path, " => ", err)
+				if err := archiveStored(path); err != nil {
+					return err
+				}
+				return journal.Record(path, "failed", err)
+			}
+			if k := page.SymbolKind(); k != "" {
+				kind = k
+			}
+
+			changed, err := store.Put(path, resp.Body, entry)
 			if err != nil {
 				return err
 			}
+			if changed {
+				fmt.Println(path, " => stored")
+			} else {
+				fmt.Println(path, " => unchanged")
+			}
 
-			if _, err := os.Stat(toTargetPath(path)); !os.IsNotExist(err) {
-				return nil
+		case http.StatusNotModified:
+			// keep validators from the previous fetch if not repeated
+			if entry.ETag == "" {
+				entry.ETag = prev.ETag
 			}
+			if entry.LastModified == "" {
+				entry.LastModified = prev.LastModified
+			}
+			if err := store.Update(path, entry); err != nil {
+				return err
+			}
+			if err := archiveStored(path); err != nil {
+				return err
+			}
+			fmt.Println(path, " => not modified")
 
-			// Process only regular files with ".json" extension
-			if !info.IsDir() && filepath.Ext(path) == ".json" {
-				data, err := ioutil.ReadFile(path)
-				if err != nil {
+		default:
+			fmt.Println(path, " => ", resp.Status)
+			if cached {
+				// keep the cached content and the validators and time of
+				// the fetch that got it, but note the failing status
+				failed := prev
+				failed.Status = resp.Status
+				if err := store.Update(path, failed); err != nil {
 					return err
 				}
-
-				var symbol Symbol
-				if err := json.Unmarshal(data, &symbol); err != nil {
+				if err := archiveStored(path); err != nil {
 					return err
 				}
-				symbol.File = path
-
-				ch <- symbol
 			}
+		}
 
-			return nil
+		stats.Record(path, kind, resp.Status, latency, len(resp.Body))
+		return journal.Record(path, "done", nil)
+	}
+
+	if *discover {
+		var allow []string
+		if *frameworks != "" {
+			allow = strings.Split(strings.ToLower(*frameworks), ",")
+		}
+		err := discoverSymbols(stop, store, allow, *depth, func(path string) error {
+			if need, err := needsFetch(path, ""); !need || err != nil {
+				return err
+			}
+			return fetchPath(path, "")
 		})
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		close(ch)
-	}()
+	} else {
+		ch := make(chan Symbol, 1024)
 
-	for sym := range ch {
+		// walking stops early on a signal, or if fetching fails
+		walk, cancelWalk := context.WithCancel(stop)
+		defer cancelWalk()
+		var walkErr error
+		go func() {
+			walkErr = filepath.Walk("./symbols", func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
 
-		if strIn(known404, sym.Path) {
-			//fmt.Println("Skipping known 404")
-			continue
+				// Process only regular files with ".json" extension
+				if !info.IsDir() && filepath.Ext(path) == ".json" {
+					data, err := ioutil.ReadFile(path)
+					if err != nil {
+						return err
+					}
+
+					var symbol Symbol
+					if err := json.Unmarshal(data, &symbol); err != nil {
+						return err
+					}
+					symbol.File = path
+					if symbol.Source != "" {
+						// loaded by doccarchive.go, not on Apple's site
+						return nil
+					}
+
+					need, err := needsFetch(strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(path), "symbols/"), ".json"), symbol.Kind)
+					if !need || err != nil {
+						return err
+					}
+
+					select {
+					case ch <- symbol:
+					case <-walk.Done():
+						return errStopped
+					}
+				}
+
+				return nil
+			})
+			close(ch)
+		}()
+
+		for sym := range ch {
+			if stop.Err() != nil {
+				break
+			}
+
+			if err := fetchPath(sym.Path, sym.Kind); err != nil {
+				return err
+			}
+		}
+		cancelWalk()
+		for range ch {
+			// wait for the walk to stop
 		}
+		if walkErr != nil && walkErr != errStopped {
+			return walkErr
+		}
+	}
+
+	stats.Finish()
+	stats.Print()
+	if err := writeJSON(*report, stats); err != nil {
+		return err
+	}
+
+	if stop.Err() != nil {
+		fmt.Printf("\nInterrupted. %d done, %d failed. Run again to resume.\n", journal.Count("done"), journal.Count("failed"))
+		return errInterrupted
+	}
+
+	failed := journal.Count("failed")
+	if err := journal.Close(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		// keep the journal so the next run only retries the failures
+		fmt.Printf("\n%d symbols failed. Run again to retry them.\n", failed)
+		return nil
+	}
+	return os.Remove("./cache/fetch.journal")
+}
+
+func newStats() *Stats {
+	return &Stats{
+		Started:     time.Now(),
+		ByStatus:    make(map[string]int),
+		ByFramework: make(map[string]map[string]int),
+		ByKind:      make(map[string]map[string]int),
+	}
+}
+
+// Record counts a request for path. status is 0 if the request failed.
+func (s *Stats) Record(path, kind string, status int, latency time.Duration, bytes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := "error"
+	if status != 0 {
+		key = strconv.Itoa(status)
+	}
+	if kind == "" {
+		kind = "Unknown"
+	}
+	s.Requests++
+	s.Bytes += int64(bytes)
+	s.latency += latency
+	if status == http.StatusNotModified {
+		s.CacheHits++
+	} else {
+		s.CacheMisses++
+	}
+	s.ByStatus[key]++
+	s.count(s.ByFramework, frameworkOf(path), key)
+	s.count(s.ByKind, kind, key)
+}
+
+// RecordCached counts a path skipped because it is fresh in the store.
+func (s *Stats) RecordCached(path, kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kind == "" {
+		kind = "Unknown"
+	}
+	s.CacheHits++
+	s.ByStatus["cached"]++
+	s.count(s.ByFramework, frameworkOf(path), "cached")
+	s.count(s.ByKind, kind, "cached")
+}
 
-		var pageText string
-		resp, err := chromedp.RunResponse(ctx,
-			chromedp.Navigate(fmt.Sprintf("https://developer.apple.com/tutorials/data/documentation/%s.json?language=objc", sym.Path)),
-			chromedp.Text("body", &pageText, chromedp.NodeVisible, chromedp.ByQuery))
-		if err != nil || resp == nil {
-			fmt.Println(sym.Path, " => ", err)
+// RecordSkipped counts a path skipped because it is on the known 404
+// list.
+func (s *Stats) RecordSkipped(path, kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kind == "" {
+		kind = "Unknown"
+	}
+	s.Skipped++
+	s.ByStatus["skipped"]++
+	s.count(s.ByFramework, frameworkOf(path), "skipped")
+	s.count(s.ByKind, kind, "skipped")
+}
+
+// RecordResumed counts a path skipped because an earlier run did it.
+func (s *Stats) RecordResumed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Resumed++
+}
+
+func (s *Stats) count(m map[string]map[string]int, group, key string) {
+	if m[group] == nil {
+		m[group] = make(map[string]int)
+	}
+	m[group][key]++
+}
+
+// Finish computes the derived fields at the end of the run.
+func (s *Stats) Finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Finished = time.Now()
+	if s.Requests > 0 {
+		s.AvgLatency = float64(s.latency.Microseconds()) / 1000 / float64(s.Requests)
+	}
+	if total := s.CacheHits + s.CacheMisses; total > 0 {
+		s.CacheHitRatio = float64(s.CacheHits) / float64(total)
+	}
+}
+
+// Print writes the end-of-run summary to stdout.
+func (s *Stats) Print() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Printf("\n%d requests, %.1f MB in %s, avg latency %.0fms\n",
+		s.Requests, float64(s.Bytes)/1e6, s.Finished.Sub(s.Started).Round(time.Second), s.AvgLatency)
+	fmt.Printf("cache: %d hits, %d misses (%.1f%% hit ratio)", s.CacheHits, s.CacheMisses, s.CacheHitRatio*100)
+	if s.Resumed > 0 {
+		fmt.Printf(", %d resumed", s.Resumed)
+	}
+	if s.Skipped > 0 {
+		fmt.Printf(", %d skipped as known 404", s.Skipped)
+	}
+	fmt.Println()
+
+	fmt.Println("\nby status:")
+	for _, k := range sortedKeys(s.ByStatus) {
+		fmt.Printf("  %-8s %d\n", k, s.ByStatus[k])
+	}
+
+	fmt.Println("\nby kind:")
+	for _, k := range sortedKeys(s.ByKind) {
+		fmt.Printf("  %-12s %s\n", k, formatCounts(s.ByKind[k]))
+	}
+
+	// frameworks with the most failed pages first, so outages and
+	// 404 waves stand out
+	failures := func(counts map[string]int) (n int) {
+		for k, c := range counts {
+			if k != "200" && k != "304" && k != "cached" && k != "skipped" {
+				n += c
+			}
+		}
+		return
+	}
+	fws := sortedKeys(s.ByFramework)
+	sort.SliceStable(fws, func(i, j int) bool {
+		return failures(s.ByFramework[fws[i]]) > failures(s.ByFramework[fws[j]])
+	})
+	if len(fws) > 20 {
+		fmt.Printf("\nby framework (top 20 of %d, full list in report):\n", len(fws))
+		fws = fws[:20]
+	} else {
+		fmt.Println("\nby framework:")
+	}
+	for _, fw := range fws {
+		fmt.Printf("  %-24s %s\n", fw, formatCounts(s.ByFramework[fw]))
+	}
+}
+
+func formatCounts(counts map[string]int) string {
+	var parts []string
+	for _, k := range sortedKeys(counts) {
+		parts = append(parts, fmt.Sprintf("%s:%d", k, counts[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func frameworkOf(path string) string {
+	return strings.SplitN(path, "/", 2)[0]
+}
+
+// discoverSymbols crawls DocC pages breadth-first from the pages of the
+// allowed frameworks, or from the technologies index if allow is empty.
+// It follows topic sections and symbol references up to depth links away,
+// staying within the allowed frameworks, and writes a stub to ./symbols
+// for every symbol page found, like load.go does from the docSet. fetch is
+// called for each page before it is read from the store.
+func discoverSymbols(stop context.Context, store *docstore.Store, allow []string, depth int, fetch func(path string) error) error {
+	type item struct {
+		path  string
+		depth int
+	}
+	var queue []item
+	seen := map[string]bool{}
+	for _, fw := range allow {
+		queue = append(queue, item{fw, 0})
+		seen[fw] = true
+	}
+	if len(allow) == 0 {
+		queue = append(queue, item{"technologies", 0})
+		seen["technologies"] = true
+	}
+
+	allowed := func(path string) bool {
+		if len(allow) == 0 {
+			return true
+		}
+		fw := strings.SplitN(path, "/", 2)[0]
+		for _, a := range allow {
+			if fw == a {
+				return true
+			}
+		}
+		return false
+	}
+
+	found := 0
+	for len(queue) > 0 && stop.Err() == nil {
+		it := queue[0]
+		queue = queue[1:]
+
+		if err := fetch(it.path); err != nil {
+			return err
+		}
+		data, err := store.Get(it.path)
+		if err != nil {
+			// not found upstream, or the fetch failed and is journaled
+			continue
+		}
+		page, err := docc.Decode(data)
+		if err != nil {
+			fmt.Println(it.path, " => ", err)
 			continue
 		}
-		if resp.Status == http.StatusOK {
-			var d any
-			if err := json.Unmarshal([]byte(pageText), &d); err != nil {
-				fmt.Println(pageText)
-				log.Fatal(err)
+
+		if kind := page.SymbolKind(); kind != "" && it.path != "technologies" {
+			s := Symbol{
+				Name: page.Metadata.Title,
+				Path: it.path,
+				Kind: kind,
+			}
+			if s.Kind == "Constant" {
+				s.Name = strings.Split(s.Name, " = ")[0]
+			}
+			symfile := filepath.Join("./symbols", fmt.Sprintf("%s.json", s.Path))
+			if _, err := os.Stat(symfile); os.IsNotExist(err) {
+				if err := os.MkdirAll(filepath.Dir(symfile), 0755); err != nil {
+					return err
+				}
+				if err := writeJSON(symfile, s); err != nil {
+					return err
+				}
+				found++
 			}
+		}
 
-			if err := os.MkdirAll(filepath.Dir(toTargetPath(sym.File)), 0755); err != nil {
-				log.Fatal(err)
+		if depth > 0 && it.depth >= depth {
+			continue
+		}
+		for _, link := range pageLinks(page) {
+			if link == "" || seen[link] || !allowed(link) {
+				continue
 			}
-			if err := ioutil.WriteFile(toTargetPath(sym.File), []byte(pageText), 0644); err != nil {
-				log.Fatal(err)
+			seen[link] = true
+			queue = append(queue, item{link, it.depth + 1})
+		}
+	}
+
+	fmt.Printf("\nDiscovered %d new symbols.\n", found)
+	return nil
+}
+
+// pageLinks returns the paths of the pages a page links to: its topic
+// section members in order, then any other symbols it references.
+func pageLinks(page *docc.RenderNode) (links []string) {
+	resolve := func(id string) string {
+		if ref, ok := page.References[id]; ok && ref.URL != "" {
+			return docc.PathFromURL(ref.URL)
+		}
+		return docc.PathFromURL(id)
+	}
+	for _, section := range page.TopicSections {
+		for _, id := range section.Identifiers {
+			links = append(links, resolve(id))
+		}
+	}
+	ids := make([]string, 0, len(page.References))
+	for id, ref := range page.References {
+		if ref.Type == "topic" && ref.Kind == "symbol" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		links = append(links, resolve(id))
+	}
+	return
+}
+
+// openJournal opens the journal at filename for appending, replaying any
+// entries left by a previous, unfinished run with the arguments run. The
+// journal of a run with other arguments is discarded.
+func openJournal(filename, run string) (*Journal, error) {
+	j := &Journal{state: make(map[string]string)}
+	resume := false
+	if f, err := os.Open(filename); err == nil {
+		dec := json.NewDecoder(f)
+		var header JournalEntry
+		if err := dec.Decode(&header); err == nil && header.Run == run {
+			resume = true
+			for {
+				var e JournalEntry
+				if err := dec.Decode(&e); err != nil {
+					// a torn last line from a crash ends the replay
+					break
+				}
+				j.state[e.Path] = e.State
 			}
+		}
+		f.Close()
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !resume {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(filename, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j.file = f
+	j.enc = json.NewEncoder(f)
+	if !resume {
+		if err := j.enc.Encode(JournalEntry{Run: run, Time: time.Now()}); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// Record appends the new state of path to the journal.
+func (j *Journal) Record(path, state string, err error) error {
+	e := JournalEntry{Path: path, State: state, Time: time.Now()}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state[path] = state
+	return j.enc.Encode(e)
+}
+
+// Done reports whether path was completed by this or a previous run.
+func (j *Journal) Done(path string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.state[path] == "done"
+}
+
+// Count returns the number of paths currently in state.
+func (j *Journal) Count(state string) (n int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, s := range j.state {
+		if s == state {
+			n++
+		}
+	}
+	return
+}
+
+// Close closes the journal file. Closing it again does nothing.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	f := j.file
+	j.file = nil
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// stubKind returns the kind in the ./symbols stub for path, or "" if
+// there is none.
+func stubKind(path string) string {
+	data, err := ioutil.ReadFile(filepath.Join("./symbols", path+".json"))
+	if err != nil {
+		return ""
+	}
+	var s Symbol
+	if err := json.Unmarshal(data, &s); err != nil {
+		return ""
+	}
+	return s.Kind
+}
+
+// pageURL returns the URL of the DocC JSON page for path.
+func pageURL(path string) string {
+	return fmt.Sprintf("https://developer.apple.com/tutorials/data/documentation/%s.json?language=objc", path)
+}
+
+func newBrowserFetcher(ctx context.Context) *browserFetcher {
+	f := &browserFetcher{ctx: ctx}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if e, ok := ev.(*network.EventResponseReceived); ok && e.Type == network.ResourceTypeDocument {
+			f.mu.Lock()
+			f.requestID = e.RequestID
+			f.mu.Unlock()
+		}
+	})
+	return f
+}
 
-			fmt.Println(sym.Path, " => ", toTargetPath(sym.File))
-		} else {
-			fmt.Println(sym.Path, " => ", resp.Status)
+func (f *browserFetcher) Fetch(url string, header http.Header) (*Response, error) {
+	headers := network.Headers{}
+	for k := range header {
+		headers[k] = header.Get(k)
+	}
+	f.mu.Lock()
+	f.requestID = ""
+	f.mu.Unlock()
+	resp, err := chromedp.RunResponse(f.ctx,
+		network.SetExtraHTTPHeaders(headers),
+		chromedp.Navigate(url))
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errors.New("no response")
+	}
+
+	r := &Response{
+		Status: int(resp.Status),
+		Header: http.Header{},
+		Date:   time.Now(),
+	}
+	for k, v := range resp.Headers {
+		if s, ok := v.(string); ok {
+			// repeated headers are joined by newlines
+			for _, vv := range strings.Split(s, "\n") {
+				r.Header.Add(k, vv)
+			}
+		}
+	}
+	if r.Status == http.StatusOK {
+		// the response body as served, not the text the browser renders
+		f.mu.Lock()
+		id := f.requestID
+		f.mu.Unlock()
+		if id == "" {
+			return nil, errors.New("no document response")
+		}
+		if err := chromedp.Run(f.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			r.Body, err = network.GetResponseBody(id).Do(ctx)
+			return err
+		})); err != nil {
+			return nil, err
 		}
+	}
+	return r, nil
+}
+
+func (f *recordingFetcher) Fetch(url string, header http.Header) (*Response, error) {
+	resp, err := f.Fetcher.Fetch(url, header)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.archive(&warc.Exchange{
+		URI:            url,
+		Date:           resp.Date,
+		RequestHeader:  header,
+		Status:         resp.Status,
+		ResponseHeader: resp.Header,
+		Body:           resp.Body,
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// archive writes x to the archive. Stored pages are archived from the
+// walk while fetches are archived from the main loop.
+func (f *recordingFetcher) archive(x *warc.Exchange) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.w.WriteExchange(x)
+}
+
+func (f *replayFetcher) Fetch(url string, header http.Header) (*Response, error) {
+	x, err := f.archive.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Status: x.Status,
+		Header: x.ResponseHeader,
+		Body:   x.Body,
+		Date:   x.Date,
+	}, nil
+}
 
+func writeJSON(filepath string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath, b, 0644); err != nil {
+		return err
 	}
+	return nil
+}
 
+// writeFileAtomic writes data to a temp file next to filename and renames
+// it into place, so an interrupted write never leaves a truncated file.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), filename)
 }
 
 func strIn(slice []string, str string) bool {
@@ -2,19 +2,19 @@ module github.com/mactypes/symbolsdb
 
 go 1.18
 
-require github.com/mattn/go-sqlite3 v1.14.17
+require (
+	github.com/chromedp/cdproto v0.0.0-20230220211738-2b1ec77315c9
+	github.com/chromedp/chromedp v0.9.1
+	github.com/davecgh/go-spew v1.1.1
+	github.com/mattn/go-sqlite3 v1.14.17
+)
 
 require (
-	github.com/chromedp/cdproto v0.0.0-20230220211738-2b1ec77315c9 // indirect
-	github.com/chromedp/chromedp v0.9.1 // indirect
 	github.com/chromedp/sysutil v1.0.0 // indirect
-	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/go-test/deep v1.1.0 // indirect
 	github.com/gobwas/httphead v0.1.0 // indirect
 	github.com/gobwas/pool v0.2.1 // indirect
 	github.com/gobwas/ws v1.1.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
-	github.com/qri-io/jsonpointer v0.1.1 // indirect
 	golang.org/x/sys v0.6.0 // indirect
 )
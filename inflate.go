@@ -2,23 +2,30 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"html"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
-	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/davecgh/go-spew/spew"
-	"github.com/qri-io/jsonpointer"
+	"github.com/mactypes/symbolsdb/docc"
+	"github.com/mactypes/symbolsdb/docstore"
+	"github.com/mactypes/symbolsdb/objc"
 )
 
 type Symbol struct {
-	Name string
-	Path string
-	Kind string
+	Name   string
+	Path   string
+	Kind   string
+	Source string `json:",omitempty"` // the .doccarchive the symbol was loaded from, if not Apple's site
 
 	Description  string            // /abstract/$content
 	Type         string            // /metadata/roleHeading
@@ -31,6 +38,66 @@ type Symbol struct {
 	Parameters   []Parameter       // /primaryContentSections/[kind=parameters]/parameters (name:/name,description:/content/0/inlineContent/$content)
 	Return       string            // /primaryContentSections/?[kind=content]/content/0/anchor=return_value ../1/inlineContent/$content
 	InheritsFrom string            // /relationshipSections/[type=inheritsFrom]/identifiers/0
+
+	ConformsTo       []string `json:",omitempty"` // /relationshipsSections/[type=conformsTo]/identifiers, as paths
+	InheritedBy      []string `json:",omitempty"` // /relationshipsSections/[type=inheritedBy]/identifiers, as paths
+	ConformingTypes  []string `json:",omitempty"` // /relationshipsSections/[type=conformingTypes]/identifiers, as paths
+	InheritedByTypes []string `json:",omitempty"` // /relationshipsSections/[type=inheritedByTypes]/identifiers, as paths
+
+	Topics          []TopicGroup `json:",omitempty"` // /topicSections (title, identifiers as paths)
+	RequiredMembers []string     `json:",omitempty"` // members in Topics of protocols whose /references entry is required
+
+	Discussion     []Block            `json:",omitempty"` // /primaryContentSections/[kind=content]/content (overview and discussion sections)
+	Tokens         []Token            `json:",omitempty"` // tokens of Declaration
+	PlatformTokens map[string][]Token `json:",omitempty"` // tokens of Declarations (key is platform)
+
+	Interface  *objc.Interface         `json:",omitempty"` // parsed Declaration of classes and protocols, with generic type parameters
+	Method     *objc.MethodSignature   `json:",omitempty"` // parsed Declaration of methods
+	Property   *objc.PropertyInfo      `json:",omitempty"` // parsed Declaration of properties
+	ParentPath string                  `json:",omitempty"` // path of the class or protocol owning a method or property
+	Required   bool                    `json:",omitempty"` // /metadata/required, set on required members of protocols
+	Fields     []objc.Field            `json:",omitempty"` // parsed Declaration of structs and unions, linked to /topicSections
+	Function   *objc.FunctionSignature `json:",omitempty"` // parsed Declaration of functions, its parameter types merged into Parameters
+
+	Alias         *objc.Type                `json:",omitempty"` // parsed Declaration of types and enums, the aliased or underlying type
+	AliasChain    []string                  `json:",omitempty"` // each step resolving Alias through other types, ending with CanonicalType
+	CanonicalType *objc.Type                `json:",omitempty"` // Alias with all typedefs resolved
+	Primitives    map[string]objc.Primitive `json:",omitempty"` // arithmetic CanonicalType per architecture
+
+	Expression string      `json:",omitempty"` // value expression in Declaration of enum cases, constants and macros
+	Value      *objc.Value `json:",omitempty"` // Expression evaluated, in the type of the enum or constant
+}
+
+// Token is a declaration token. Kind is the DocC token kind: keyword,
+// identifier, typeIdentifier, externalParam, internalParam, text, ...
+// Path links type identifiers to their symbol in the database.
+type Token struct {
+	Kind              string
+	Text              string
+	PreciseIdentifier string `json:",omitempty"`
+	Path              string `json:",omitempty"`
+}
+
+// Block is a block of rich content. Inline content is flattened to Text
+// the same way as Description.
+type Block struct {
+	Kind    string    // paragraph, heading, aside, list, termList, table or code
+	Text    string    `json:",omitempty"` // paragraph, heading, code
+	Level   int       `json:",omitempty"` // heading
+	Style   string    `json:",omitempty"` // aside style: note, important, warning, tip, ...
+	Name    string    `json:",omitempty"` // aside display name
+	Content []Block   `json:",omitempty"` // aside
+	Ordered bool      `json:",omitempty"` // list
+	Items   [][]Block `json:",omitempty"` // list
+	Terms   []Term    `json:",omitempty"` // termList
+	Header  string    `json:",omitempty"` // table: row, column, both
+	Rows    [][]Block `json:",omitempty"` // table rows of cells, each cell a paragraph
+	Syntax  string    `json:",omitempty"` // code
+}
+
+type Term struct {
+	Term       string
+	Definition []Block
 }
 
 type Platform struct {
@@ -42,22 +109,116 @@ type Platform struct {
 	DeprecatedAt string
 }
 
+// TopicGroup is a task group of members, like "Creating a Window".
+type TopicGroup struct {
+	Title   string
+	Members []string // paths, in Apple's order
+}
+
 type Parameter struct {
 	Name        string
 	Description string
+	Type        *objc.Type `json:",omitempty"` // from the parsed Declaration
+}
+
+// Diagnostic is a problem found while inflating a symbol. Pointer is the
+// JSON pointer into the symbol's DocC page, if it is about the page.
+type Diagnostic struct {
+	Severity string // "error", "warning", or "info" for unresolved references
+	Path     string
+	Pointer  string
+	Message  string
+	Detail   string `json:",omitempty"`
+}
+
+// Diagnostics collects problems across the run instead of stopping at the
+// first odd page.
+type Diagnostics struct {
+	Errors      int
+	Warnings    int
+	References  int // unresolved references, which are expected for links out of the database
+	Diagnostics []Diagnostic
+	Unresolved  map[string]int // reference identifiers not resolved, with counts
+
+	current string // symbol path being inflated
 }
 
 var known404 []string
 
+// aliased types of the Type and Enum symbols by path, and their symbol
+// files. Names can be declared by several frameworks, so each resolves to
+// a list of candidate paths.
+var (
+	aliases       = map[string]objc.Type{}
+	aliasesByName = map[string][]string{}
+	aliasFiles    []string
+)
+
+// constants with a value expression, and enum cases with an implicit
+// value, by path in the order inflated. Names can be declared by several
+// frameworks, so each resolves to a list of candidate paths.
+var (
+	constants       = map[string]*constant{}
+	constantPaths   []string
+	constantsByName = map[string][]string{}
+)
+
+// underlying types of the Enum symbols, and their members in topic
+// order, by path
+var (
+	enumTypes = map[string]objc.Type{}
+	enumCases = map[string][]string{}
+)
+
+type constant struct {
+	file  string
+	path  string
+	decl  *objc.Constant
+	enum  string // path of the enum of an enum case
+	scope objc.Scope
+	value *objc.Value
+	err   error
+	state int // 0 not evaluated, 1 evaluating, 2 done
+}
+
+var store *docstore.Store
+
+var diags = &Diagnostics{Unresolved: map[string]int{}}
+
+// the DocC page being inflated, its references map resolves identifiers
+var page *docc.RenderNode
+
+// output format for inline content: text, markdown or html
+var format = "text"
+
 func main() {
+	strict := flag.Bool("strict", false, "exit non-zero if there are any errors or warnings, not counting unresolved references")
+	report := flag.String("report", "./cache/inflate-report.json", "write diagnostics as JSON to this file")
+	flag.StringVar(&format, "format", "text", "render descriptions and discussion as text, markdown or html")
+	flag.Parse()
+
+	switch format {
+	case "text", "markdown", "html":
+	default:
+		log.Fatal("unknown format ", format)
+	}
+
 	var err error
 	known404, err = readFileLines("./404")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	if len(os.Args) > 1 {
-		spew.Dump(inflate(fmt.Sprintf("./symbols/%s.json", os.Args[1])))
+	store, err = docstore.OpenReadOnly("./cache/store")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	if flag.NArg() > 0 {
+		sym, _ := inflate(fmt.Sprintf("./symbols/%s.json", flag.Arg(0)))
+		spew.Dump(sym)
+		diags.Print()
 		return
 	}
 
@@ -68,12 +229,17 @@ func main() {
 
 		if !info.IsDir() && filepath.Ext(path) == ".json" {
 			//fmt.Println(path)
-			s := inflate(path)
-			b, err := json.MarshalIndent(s, "", "  ")
-			if err != nil {
-				log.Fatal(err)
+			s, ok := inflate(path)
+			if !ok {
+				// leave the file as it was
+				return nil
+			}
+			if s.Alias != nil {
+				aliases[s.Path] = *s.Alias
+				aliasesByName[s.Name] = append(aliasesByName[s.Name], s.Path)
+				aliasFiles = append(aliasFiles, path)
 			}
-			if err := ioutil.WriteFile(path, b, 0644); err != nil {
+			if err := writeSymbol(path, s); err != nil {
 				log.Fatal(err)
 			}
 		}
@@ -83,26 +249,81 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-}
 
-func inflate(symbolPath string) Symbol {
-	metaPath := strings.Replace(symbolPath, "symbols", "cache/meta", 1)
+	fmt.Println("Resolving type aliases...")
+	for _, path := range aliasFiles {
+		sym, err := loadData[Symbol](path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		resolveAlias(&sym)
+		if err := writeSymbol(path, sym); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Println("Evaluating constants...")
+	for _, path := range constantPaths {
+		c := constants[path]
+		diags.Begin(c.path)
+		evalConstant(path)
+		if c.err != nil {
+			if c.err != objc.ErrNotNumeric {
+				diags.WarnDetail("", "evaluating constant", c.err.Error())
+			}
+			continue
+		}
+		sym, err := loadData[Symbol](c.file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sym.Value = c.value
+		if err := writeSymbol(c.file, sym); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	diags.Print()
+	if err := writeJSON(*report, diags); err != nil {
+		log.Fatal(err)
+	}
+	if *strict && diags.Errors+diags.Warnings > 0 {
+		os.Exit(1)
+	}
+}
 
+// inflate fills in the symbol stub at symbolPath from its DocC page. It
+// returns false if the symbol could not be read or has no usable page.
+func inflate(symbolPath string) (Symbol, bool) {
 	sym, err := loadData[Symbol](symbolPath)
 	if err != nil {
-		log.Fatal(err, " ", symbolPath)
+		diags.Begin(strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(symbolPath), "symbols/"), ".json"))
+		diags.Error("", "reading symbol: %v", err)
+		return sym, false
 	}
+	diags.Begin(sym.Path)
 
 	if strIn(known404, sym.Path) {
-		return sym
+		return sym, true
 	}
 
-	doc, err := loadData[map[string]interface{}](metaPath)
+	b, err := store.Get(sym.Path)
+	if err != nil {
+		diags.Error("", "reading page: %v", err)
+		return sym, false
+	}
+	doc, err := docc.Decode(b)
 	if err != nil {
-		log.Fatal(err, " ", metaPath)
+		diags.Error("", "%v", err)
+		return sym, false
 	}
+	page = doc
 
-	fmt.Println(metaPath)
+	// start over from the stub, so nothing from an earlier inflate of the
+	// file survives, whether appended to or left by a failing parse
+	sym = Symbol{Name: sym.Name, Path: sym.Path, Kind: sym.Kind, Source: sym.Source}
+
+	fmt.Println(sym.Path)
 
 	// fix bug in constant names
 	if sym.Kind == "Constant" {
@@ -110,74 +331,85 @@ func inflate(symbolPath string) Symbol {
 	}
 
 	// Description
-	if abstract := findPath(doc, "/abstract"); abstract != nil {
-		sym.Description = strings.Trim(parseContent(abstract), " ")
+	if doc.Abstract != nil {
+		sym.Description = strings.Trim(parseContent(doc.Abstract, "/abstract"), " ")
 	}
 	// Type
-	if typ := findPath(doc, "/metadata/roleHeading"); typ != nil {
-		sym.Type = typ.(string)
-	}
+	sym.Type = doc.Metadata.RoleHeading
 	// Platforms
-	sym.Platforms = parsePlatforms(findPath(doc, "/metadata/platforms"))
+	sym.Platforms = parsePlatforms(doc.Metadata.Platforms)
 	// Modules
-	if mods := findPath(doc, "/metadata/modules"); mods != nil {
+	if doc.Metadata.Modules != nil {
 		sym.Modules = []string{}
-		for _, m := range mods.([]any) {
-			sym.Modules = append(sym.Modules, findPath(m, "/name").(string))
+		for _, m := range doc.Metadata.Modules {
+			sym.Modules = append(sym.Modules, m.Name)
 		}
 	}
 	// Parent
-	if parent := findPath(doc, "/metadata/parent/title"); parent != nil {
-		sym.Parent = parent.(string)
+	if doc.Metadata.Parent != nil {
+		sym.Parent = doc.Metadata.Parent.Title
 	}
 
-	if content := findPath(doc, "/primaryContentSections"); content != nil {
+	if doc.PrimaryContentSections != nil {
 		// Parameters
-		if paramContent := findWithProp(content, "kind", "parameters"); paramContent != nil {
-			if params := findPath(paramContent, "/parameters"); params != nil {
-				sym.Parameters = []Parameter{}
-				for _, param := range params.([]any) {
-					sym.Parameters = append(sym.Parameters, Parameter{
-						Name:        findPath(param, "/name").(string),
-						Description: parseContent(findPath(param, "/content/0/inlineContent")),
-					})
+		if idx, paramContent := doc.Section("parameters"); paramContent != nil && paramContent.Parameters != nil {
+			sym.Parameters = []Parameter{}
+			for i, param := range paramContent.Parameters {
+				p := Parameter{Name: param.Name}
+				if len(param.Content) > 0 {
+					p.Description = parseContent(param.Content[0].InlineContent,
+						fmt.Sprintf("/primaryContentSections/%d/parameters/%d/content/0/inlineContent", idx, i))
 				}
+				sym.Parameters = append(sym.Parameters, p)
 			}
 		}
 		// Return
-		for _, potentialRet := range content.([]any) {
-			if anchor := findPath(potentialRet, "/content/0/anchor"); anchor != nil && anchor.(string) == "return_value" {
-				sym.Return = parseContent(findPath(potentialRet, "/content/1/inlineContent"))
+		for idx, potentialRet := range doc.PrimaryContentSections {
+			if len(potentialRet.Content) > 0 && potentialRet.Content[0].Anchor == "return_value" {
+				if len(potentialRet.Content) > 1 {
+					sym.Return = parseContent(potentialRet.Content[1].InlineContent,
+						fmt.Sprintf("/primaryContentSections/%d/content/1/inlineContent", idx))
+				} else {
+					sym.Return = ""
+				}
+			}
+		}
+		// Discussion
+		for idx, section := range doc.PrimaryContentSections {
+			if section.Kind != "content" || len(section.Content) == 0 {
+				continue
+			}
+			if head := section.Content[0]; head.Type == "heading" &&
+				(head.Anchor == "overview" || head.Anchor == "discussion") {
+				sym.Discussion = append(sym.Discussion,
+					parseBlocks(section.Content[1:], fmt.Sprintf("/primaryContentSections/%d/content", idx), 1)...)
 			}
 		}
 		// Declaration
 		sym.Declarations = make(map[string]string)
-		if declContent := findWithProp(content, "kind", "declarations"); declContent != nil {
-			if decls := findPath(declContent, "/declarations"); decls != nil {
-				for _, decl := range decls.([]any) {
-					langs := findPath(decl, "/languages")
-					if langs == nil {
-						continue
-					}
-					found := false
-					for _, lang := range langs.([]any) {
-						if lang.(string) == "occ" {
-							found = true
-						}
-					}
-					if !found {
-						continue
-					}
-					declStr := buildDeclarationFromTokens(findPath(decl, "/tokens"))
-					platforms := findPath(decl, "/platforms")
-					if platforms == nil {
-						panic("platforms not found for declaration on " + sym.Path)
-					}
-					for _, platform := range platforms.([]any) {
-						platName := strings.ToLower(platform.(string))
-						sym.Declarations[platName] = declStr
+		sym.PlatformTokens = make(map[string][]Token)
+		if idx, declContent := doc.Section("declarations"); declContent != nil {
+			for i, decl := range declContent.Declarations {
+				found := false
+				for _, lang := range decl.Languages {
+					if lang == "occ" {
+						found = true
 					}
 				}
+				if !found {
+					continue
+				}
+				declStr := buildDeclarationFromTokens(decl.Tokens)
+				tokens := parseTokens(decl.Tokens)
+				if decl.Platforms == nil {
+					diags.Error(fmt.Sprintf("/primaryContentSections/%d/declarations/%d/platforms", idx, i), "no platforms for declaration")
+					continue
+				}
+				for _, platform := range decl.Platforms {
+					platName := strings.ToLower(platform)
+					sym.Declarations[platName] = declStr
+					sym.PlatformTokens[platName] = tokens
+				}
 			}
 		}
 		// if all Declarations are the same, unset Declarations and set Declaration
@@ -194,45 +426,545 @@ func inflate(symbolPath string) Symbol {
 			}
 		}
 		if same {
+			for _, t := range sym.PlatformTokens {
+				sym.Tokens = t
+				break
+			}
 			sym.Declarations = nil
+			sym.PlatformTokens = nil
 			sym.Declaration = decl
 		}
 	}
 
+	// Interface
+	if decl := primaryDeclaration(sym); (sym.Kind == "Class" || sym.Kind == "Protocol") && decl != "" {
+		it, err := objc.ParseInterface(decl)
+		if err != nil {
+			diags.WarnDetail("/primaryContentSections", "parsing "+strings.ToLower(sym.Kind)+" declaration", err.Error())
+		}
+		sym.Interface = it
+	}
+
+	// Method
+	if decl := primaryDeclaration(sym); sym.Kind == "Method" && decl != "" {
+		m, err := objc.ParseMethod(decl)
+		if err != nil {
+			diags.WarnDetail("/primaryContentSections", "parsing method declaration", err.Error())
+		}
+		sym.Method = m
+		sym.ParentPath = parentPath(sym.Path)
+	}
+
+	// Property
+	if decl := primaryDeclaration(sym); sym.Kind == "Property" && decl != "" {
+		prop, err := objc.ParseProperty(decl)
+		if err != nil {
+			diags.WarnDetail("/primaryContentSections", "parsing property declaration", err.Error())
+		}
+		sym.Property = prop
+		sym.ParentPath = parentPath(sym.Path)
+	}
+
+	// Fields
+	if decl := primaryDeclaration(sym); (sym.Kind == "Struct" || sym.Kind == "Union") && decl != "" {
+		r, err := objc.ParseRecord(decl)
+		if err != nil {
+			diags.WarnDetail("/primaryContentSections", "parsing "+strings.ToLower(sym.Kind)+" declaration", err.Error())
+		} else {
+			sym.Fields = r.Fields
+			linkFields(doc, sym.Fields)
+		}
+	}
+
+	// Function
+	if decl := primaryDeclaration(sym); sym.Kind == "Function" && decl != "" {
+		f, err := objc.ParseFunction(decl)
+		if err != nil {
+			diags.WarnDetail("/primaryContentSections", "parsing function declaration", err.Error())
+		} else {
+			sym.Function = f
+			sym.Parameters = mergeParameters(sym.Parameters, f)
+		}
+	}
+
+	// Alias
+	if decl := primaryDeclaration(sym); sym.Kind == "Type" && decl != "" {
+		td, err := objc.ParseTypedef(decl)
+		if err != nil {
+			diags.WarnDetail("/primaryContentSections", "parsing type declaration", err.Error())
+		} else {
+			sym.Alias = &td.Type
+		}
+	}
+
+	// Enum underlying type
+	if decl := primaryDeclaration(sym); sym.Kind == "Enum" && decl != "" {
+		td, err := objc.ParseEnum(decl)
+		if err != nil {
+			diags.WarnDetail("/primaryContentSections", "parsing enum declaration", err.Error())
+		} else {
+			if td.Name != "" {
+				// an anonymous enum names no type
+				sym.Alias = &td.Type
+			}
+			enumTypes[sym.Path] = td.Type
+		}
+	}
+
+	// Expression
+	if decl := primaryDeclaration(sym); (sym.Kind == "Constant" || sym.Kind == "Macro") && decl != "" {
+		c, err := objc.ParseConstant(decl)
+		if err != nil {
+			diags.WarnDetail("/primaryContentSections", "parsing "+strings.ToLower(sym.Kind)+" declaration", err.Error())
+		} else if enum := parentPath(sym.Path); c.Expr != "" || (c.Type == nil && enum != "" && sym.Kind == "Constant") {
+			// a case without a value expression is implicitly the
+			// previous case plus one
+			sym.Expression = c.Expr
+			addConstant(&constant{file: symbolPath, path: sym.Path, decl: c, enum: enum})
+		}
+	}
+
 	// Deprecated
-	if findPath(doc, "/deprecationSummary") != nil {
+	if doc.DeprecationSummary != nil {
 		sym.Deprecated = true
 	}
 
 	// InheritsFrom
-	if sections := findPath(doc, "/relationshipsSections"); sections != nil {
-		if inheritsFrom := findWithProp(sections, "type", "inheritsFrom"); inheritsFrom != nil {
-			if id := findPath(inheritsFrom, "/identifiers/0"); id != nil {
-				sym.InheritsFrom = strings.Replace(id.(string), "doc://com.apple.documentation/documentation/", "", 1)
-			}
+	if inheritsFrom := doc.Relationships("inheritsFrom"); inheritsFrom != nil && len(inheritsFrom.Identifiers) > 0 {
+		sym.InheritsFrom = strings.Replace(inheritsFrom.Identifiers[0], "doc://com.apple.documentation/documentation/", "", 1)
+	}
+
+	// Relationships
+	sym.ConformsTo = relationshipPaths(doc, "conformsTo")
+	sym.InheritedBy = relationshipPaths(doc, "inheritedBy")
+	sym.ConformingTypes = relationshipPaths(doc, "conformingTypes")
+	sym.InheritedByTypes = relationshipPaths(doc, "inheritedByTypes")
+
+	// Topics
+	sym.Topics = parseTopics(doc)
+	if sym.Kind == "Enum" {
+		var cases []string
+		for _, group := range sym.Topics {
+			cases = append(cases, group.Members...)
 		}
+		enumCases[sym.Path] = cases
+	}
+	if sym.Kind == "Protocol" {
+		sym.RequiredMembers = requiredMembers(doc, sym.Topics)
 	}
 
+	// Required
+	sym.Required = doc.Metadata.Required
+
 	// sanity check declaration, unless any of these cases...
 	ignoreDeclaration := false
-	if role := findPath(doc, "/metadata/role"); role != nil &&
-		(role.(string) == "collectionGroup" ||
-			role.(string) == "dictionarySymbol") {
+	if doc.Metadata.Role == "collectionGroup" ||
+		doc.Metadata.Role == "dictionarySymbol" {
 		ignoreDeclaration = true
 	}
 	if strings.HasPrefix(sym.Path, "kernel") {
 		ignoreDeclaration = true
 	}
-	if lang := findPath(doc, "/identifier/interfaceLanguage"); lang != nil {
-		if lang.(string) == "swift" {
-			ignoreDeclaration = true
-		}
+	if doc.Identifier.InterfaceLanguage == "swift" {
+		ignoreDeclaration = true
 	}
 	if sym.Kind != "Framework" && sym.Declaration == "" && len(sym.Declarations) == 0 && !sym.Deprecated && sym.Type != "" && !ignoreDeclaration {
-		log.Fatal("no declaration ", sym.Path, " ", sym.Kind)
+		diags.Error("/primaryContentSections", "no declaration for %s", sym.Kind)
+	}
+
+	return sym, true
+}
+
+// primaryDeclaration returns Declaration, or when declarations differ by
+// platform, the first platform's. They usually differ only in attributes.
+func primaryDeclaration(sym Symbol) string {
+	if sym.Declaration != "" || len(sym.Declarations) == 0 {
+		return sym.Declaration
+	}
+	plats := make([]string, 0, len(sym.Declarations))
+	for plat := range sym.Declarations {
+		plats = append(plats, plat)
+	}
+	sort.Strings(plats)
+	return sym.Declarations[plats[0]]
+}
+
+// parentPath returns the path of the symbol owning the member at path,
+// if it is in the database.
+func parentPath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return ""
+	}
+	parent := path[:i]
+	if !inDatabase(parent) {
+		return ""
+	}
+	return parent
+}
+
+// known caches whether symbol paths are in the database.
+var known = map[string]bool{}
+
+// inDatabase reports whether there is a symbol at path.
+func inDatabase(path string) bool {
+	in, ok := known[path]
+	if !ok {
+		_, err := os.Stat(filepath.Join("./symbols", path+".json"))
+		in = err == nil
+		known[path] = in
+	}
+	return in
+}
+
+// relationshipPaths returns the paths of the symbols in the relationships
+// section of typ, in page order.
+func relationshipPaths(doc *docc.RenderNode, typ string) []string {
+	var paths []string
+	for i, section := range doc.RelationshipsSections {
+		if section.Type != typ {
+			continue
+		}
+		paths = append(paths, identifierPaths(section.Identifiers, fmt.Sprintf("/relationshipsSections/%d/identifiers", i))...)
+	}
+	return paths
+}
+
+// parseTopics returns the page's task groups of members, in page order.
+func parseTopics(doc *docc.RenderNode) []TopicGroup {
+	var groups []TopicGroup
+	for i, section := range doc.TopicSections {
+		groups = append(groups, TopicGroup{
+			Title:   section.Title,
+			Members: identifierPaths(section.Identifiers, fmt.Sprintf("/topicSections/%d/identifiers", i)),
+		})
+	}
+	return groups
+}
+
+// requiredMembers returns the paths, from topics parsed from the page,
+// of the members of a protocol that conforming types must implement.
+func requiredMembers(doc *docc.RenderNode, topics []TopicGroup) []string {
+	var paths []string
+	for i, section := range doc.TopicSections {
+		for j, id := range section.Identifiers {
+			if ref, ok := doc.References[id]; ok && ref.Required {
+				paths = append(paths, topics[i].Members[j])
+			}
+		}
+	}
+	return paths
+}
+
+// identifierPaths resolves reference identifiers to paths. Unresolved
+// identifiers keep the path spelled by their URL.
+func identifierPaths(ids []string, ptr string) []string {
+	var paths []string
+	for i, id := range ids {
+		_, path, ok := resolveRef(id)
+		if !ok {
+			diags.Unresolve(fmt.Sprintf("%s/%d", ptr, i), id)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// linkFields sets the Path of fields documented on their own page, found
+// by title among the page's topics.
+func linkFields(doc *docc.RenderNode, fields []objc.Field) {
+	objc.LinkFields(fields, func(name string) string {
+		for _, section := range doc.TopicSections {
+			for _, id := range section.Identifiers {
+				if ref, ok := doc.References[id]; ok && ref.Title == name {
+					if _, path, ok := resolveRef(id); ok {
+						return path
+					}
+				}
+			}
+		}
+		return ""
+	})
+}
+
+// mergeParameters returns the parameters of f in declaration order with
+// their documentation, reporting parameters documented but not declared
+// and, when any are documented, declared but not documented.
+func mergeParameters(documented []Parameter, f *objc.FunctionSignature) []Parameter {
+	docs := make(map[string]Parameter)
+	var names []string
+	for _, p := range documented {
+		docs[p.Name] = p
+		names = append(names, p.Name)
+	}
+	undocumented, undeclared := f.MatchParams(names)
+	for _, name := range undocumented {
+		diags.WarnDetail("/primaryContentSections", "declared parameter not documented", name)
+	}
+	for _, name := range undeclared {
+		diags.WarnDetail("/primaryContentSections", "documented parameter not declared", name)
+	}
+
+	var params []Parameter
+	for i := range f.Params {
+		p, ok := docs[f.Params[i].Name]
+		if !ok {
+			p.Name = f.Params[i].Name
+		}
+		delete(docs, p.Name)
+		p.Type = &f.Params[i].Type
+		params = append(params, p)
+	}
+	for _, p := range documented {
+		if _, ok := docs[p.Name]; ok {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// resolveAlias resolves the Alias of a Type symbol through the other
+// Type symbols in the database.
+func resolveAlias(sym *Symbol) {
+	chain, canonical := objc.Canonicalize(*sym.Alias, aliasLookup(framework(sym.Path)))
+	names := []string{sym.Name, sym.Alias.Base}
+	sym.AliasChain = nil
+	for _, t := range chain {
+		sym.AliasChain = append(sym.AliasChain, t.Spelling)
+		names = append(names, t.Base)
+	}
+	sym.CanonicalType = &canonical
+	sym.Primitives = objc.Primitives(names, canonical)
+}
+
+// addConstant registers a constant for evaluation once all symbols are
+// inflated.
+func addConstant(c *constant) {
+	if constants[c.path] == nil {
+		constantPaths = append(constantPaths, c.path)
+		constantsByName[c.decl.Name] = append(constantsByName[c.decl.Name], c.path)
+	}
+	c.scope = objc.Scope{
+		Const: func(name string) (objc.Value, bool) { return evalConstant(lookupConstant(name, c)) },
+		Type: func(t objc.Type) (int, bool, bool, bool) {
+			return typeLayout(t, framework(c.path))
+		},
+	}
+	constants[c.path] = c
+}
+
+// lookupConstant returns the path of the constant name refers to in the
+// value of c: preferably a case of the same enum, then a constant of the
+// same framework, then the first declared.
+func lookupConstant(name string, c *constant) string {
+	candidates := constantsByName[name]
+	if len(candidates) == 0 {
+		return ""
+	}
+	best := candidates[0]
+	for _, path := range candidates {
+		other := constants[path]
+		if c.enum != "" && other.enum == c.enum {
+			return path
+		}
+		if framework(path) == framework(c.path) && framework(best) != framework(c.path) {
+			best = path
+		}
+	}
+	return best
+}
+
+// evalConstant evaluates the constant at path, and the constants its
+// value refers to, converting the value to the type of the constant.
+func evalConstant(path string) (objc.Value, bool) {
+	c := constants[path]
+	if c == nil {
+		return objc.Value{}, false
+	}
+	switch {
+	case c.state == 1:
+		// refers to itself
+		return objc.Value{}, false
+	case c.state == 2 && c.err != nil:
+		return objc.Value{}, false
+	case c.state == 2:
+		return *c.value, true
+	}
+	c.state = 1
+	var v objc.Value
+	var err error
+	if c.decl.Expr == "" {
+		v, err = implicitValue(c)
+	} else {
+		v, err = objc.Eval(c.decl.Expr, c.scope)
+	}
+	c.state = 2
+	if err != nil {
+		c.err = err
+		return objc.Value{}, false
+	}
+	t := c.decl.Type
+	if enum, ok := enumTypes[c.enum]; ok {
+		t = &enum
+	}
+	if t != nil {
+		if width, signed, float, ok := typeLayout(*t, framework(c.path)); ok {
+			v = v.Convert(width, signed, float)
+		}
 	}
+	c.value = &v
+	return v, true
+}
+
+// implicitValue returns the value of an enum case declared without one:
+// the value of the previous case in topic order plus one, or zero for
+// the first case.
+func implicitValue(c *constant) (objc.Value, error) {
+	cases, ok := enumCases[c.enum]
+	if _, isEnum := enumTypes[c.enum]; !ok || !isEnum {
+		return objc.Value{}, objc.ErrNotNumeric
+	}
+	i := 0
+	for i < len(cases) && cases[i] != c.path {
+		i++
+	}
+	if i == len(cases) {
+		return objc.Value{}, fmt.Errorf("enum case not among the topics of %s", c.enum)
+	}
+	for i--; i >= 0; i-- {
+		if constants[cases[i]] == nil {
+			continue
+		}
+		prev, ok := evalConstant(cases[i])
+		if !ok {
+			return objc.Value{}, fmt.Errorf("previous case %s has no value", cases[i])
+		}
+		return prev.Next(), nil
+	}
+	return objc.Value{Number: "0", Signed: true, Width: 32}, nil
+}
+
+// typeLayout returns the width and signedness of an arithmetic type on
+// arm64, resolving typedefs as seen from framework.
+func typeLayout(t objc.Type, framework string) (width int, signed, float, ok bool) {
+	chain, canonical := objc.Canonicalize(t, aliasLookup(framework))
+	names := []string{t.Base}
+	for _, t := range chain {
+		names = append(names, t.Base)
+	}
+	prim, ok := objc.Primitives(names, canonical)["arm64"]
+	return prim.Size * 8, prim.Signed, prim.Float, ok
+}
+
+// aliasLookup returns a function finding the aliased type of a typedef
+// name as seen from framework: preferably its own, then the first
+// declared.
+func aliasLookup(from string) func(name string) (objc.Type, bool) {
+	return func(name string) (objc.Type, bool) {
+		candidates := aliasesByName[name]
+		if len(candidates) == 0 {
+			return objc.Type{}, false
+		}
+		best := candidates[0]
+		for _, path := range candidates {
+			if framework(path) == from {
+				best = path
+				break
+			}
+		}
+		return aliases[best], true
+	}
+}
+
+// framework returns the framework of a symbol path, appkit of
+// appkit/nswindow.
+func framework(path string) string {
+	return strings.SplitN(path, "/", 2)[0]
+}
+
+// Begin sets the symbol that following diagnostics are about.
+func (d *Diagnostics) Begin(path string) {
+	d.current = path
+}
+
+func (d *Diagnostics) Error(pointer, format string, args ...any) {
+	d.Errors++
+	d.add("error", pointer, format, args...)
+}
+
+func (d *Diagnostics) Warn(pointer, format string, args ...any) {
+	d.Warnings++
+	d.add("warning", pointer, format, args...)
+}
+
+// WarnDetail records a warning whose detail varies by symbol, so the
+// summary counts it under one message.
+func (d *Diagnostics) WarnDetail(pointer, message, detail string) {
+	d.Warnings++
+	d.addDiagnostic(Diagnostic{
+		Severity: "warning",
+		Path:     d.current,
+		Pointer:  pointer,
+		Message:  message,
+		Detail:   detail,
+	})
+}
+
+func (d *Diagnostics) add(severity, pointer, format string, args ...any) {
+	d.addDiagnostic(Diagnostic{
+		Severity: severity,
+		Path:     d.current,
+		Pointer:  pointer,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *Diagnostics) addDiagnostic(diag Diagnostic) {
+	d.Diagnostics = append(d.Diagnostics, diag)
+	pointer := diag.Pointer
+	if pointer != "" {
+		// like a URI fragment
+		pointer = "#" + pointer
+	}
+	msg := diag.Message
+	if diag.Detail != "" {
+		msg += ": " + diag.Detail
+	}
+	fmt.Printf("%s: %s%s: %s\n", diag.Severity, diag.Path, pointer, msg)
+}
+
+// Unresolve records a reference identifier that could not be resolved.
+// Pages link to symbols outside the database, so it is not a warning.
+func (d *Diagnostics) Unresolve(pointer, identifier string) {
+	d.Unresolved[identifier]++
+	d.References++
+	d.Diagnostics = append(d.Diagnostics, Diagnostic{
+		Severity: "info",
+		Path:     d.current,
+		Pointer:  pointer,
+		Message:  "unresolved reference",
+		Detail:   identifier,
+	})
+}
 
-	return sym
+// Print writes a summary of the diagnostics by message.
+func (d *Diagnostics) Print() {
+	if len(d.Diagnostics) == 0 {
+		return
+	}
+	counts := map[string]int{}
+	var messages []string
+	for _, diag := range d.Diagnostics {
+		key := diag.Severity + ": " + diag.Message
+		if counts[key] == 0 {
+			messages = append(messages, key)
+		}
+		counts[key]++
+	}
+	fmt.Printf("\n%d errors, %d warnings, %d unresolved references:\n", d.Errors, d.Warnings, d.References)
+	for _, m := range messages {
+		fmt.Printf("  %6d  %s\n", counts[m], m)
+	}
 }
 
 func loadData[T any](filepath string) (v T, err error) {
@@ -246,42 +978,238 @@ func loadData[T any](filepath string) (v T, err error) {
 	return
 }
 
-func parseContent(content any) string {
-	if content == nil {
-		return ""
-	}
+// parseContent renders inline content in the -format output format:
+// plain text, or Markdown or HTML keeping code voice, emphasis and
+// links to referenced symbols by their database path.
+func parseContent(content []docc.InlineContent, ptr string) string {
 	str := ""
-	for _, part := range content.([]any) {
-		typ := findPath(part, "/type")
-		if typ == nil {
+	for i, part := range content {
+		inner := fmt.Sprintf("%s/%d/inlineContent", ptr, i)
+		switch part.Type {
+		case "":
 			continue
-		}
-		switch typ {
 		case "text":
-			if text := findPath(part, "/text"); text != nil {
-				str += text.(string)
-			}
+			// text starting a line could be taken for a block marker
+			str += escapeLineStarts(escapeText(part.Text), str == "" || strings.HasSuffix(str, "\n"))
 		case "codeVoice":
-			if code := findPath(part, "/code"); code != nil {
-				str += code.(string)
-			}
+			str += formatCode(part.Code)
 		case "inlineHead":
-			str += parseContent(findPath(part, "/inlineContent")) + ": "
-		case "emphasis", "strong", "newTerm", "superscript":
-			str += parseContent(findPath(part, "/inlineContent"))
+			str += formatTag("strong", parseContent(part.InlineContent, inner)) + ": "
+		case "emphasis", "newTerm":
+			str += formatTag("em", parseContent(part.InlineContent, inner))
+		case "strong":
+			str += formatTag("strong", parseContent(part.InlineContent, inner))
+		case "superscript":
+			str += formatTag("sup", parseContent(part.InlineContent, inner))
+		case "subscript":
+			str += formatTag("sub", parseContent(part.InlineContent, inner))
+		case "strikethrough":
+			str += formatTag("s", parseContent(part.InlineContent, inner))
+		case "link":
+			str += formatLink(part.Title, part.Destination)
 		case "reference":
-			if id := findPath(part, "/identifier"); id != nil {
-				str += resolveRefName(id.(string))
+			if part.Identifier != "" {
+				str += formatRef(part.Identifier, fmt.Sprintf("%s/%d/identifier", ptr, i))
 			}
 		default:
-			log.Fatal("unknown content part type: ", typ)
+			diags.Warn(fmt.Sprintf("%s/%d/type", ptr, i), "unknown content part type %q", part.Type)
 		}
 	}
 	return str
 }
 
-func resolveRefName(identifier string) string {
-	path := strings.Replace(identifier, "doc://com.apple.documentation/documentation/", "", 1)
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`)
+
+// Markdown block markers at the start of a line: headings, list items and
+// block quotes
+var blockMarker = regexp.MustCompile(`(?m)^( {0,3})(#|>|\+|-|\d+[.)])`)
+
+// escapeLineStarts escapes the Markdown block markers starting the lines
+// of text, including the first if it starts a line.
+func escapeLineStarts(text string, atStart bool) string {
+	if format != "markdown" {
+		return text
+	}
+	escape := func(s string) string {
+		return blockMarker.ReplaceAllStringFunc(s, func(m string) string {
+			i := len(m) - 1
+			return m[:i] + `\` + m[i:]
+		})
+	}
+	if atStart {
+		return escape(text)
+	}
+	if i := strings.Index(text, "\n"); i >= 0 {
+		return text[:i] + escape(text[i:])
+	}
+	return text
+}
+
+func escapeText(text string) string {
+	switch format {
+	case "markdown":
+		return markdownEscaper.Replace(text)
+	case "html":
+		return html.EscapeString(text)
+	}
+	return text
+}
+
+func formatCode(code string) string {
+	switch format {
+	case "markdown":
+		if strings.Contains(code, "`") {
+			return "`` " + code + " ``"
+		}
+		return "`" + code + "`"
+	case "html":
+		return "<code>" + html.EscapeString(code) + "</code>"
+	}
+	return code
+}
+
+// formatTag wraps already formatted text in an HTML tag, or its Markdown
+// equivalent where there is one.
+func formatTag(tag, text string) string {
+	switch format {
+	case "markdown":
+		switch tag {
+		case "em":
+			return "*" + text + "*"
+		case "strong":
+			return "**" + text + "**"
+		case "s":
+			return "~~" + text + "~~"
+		}
+		return "<" + tag + ">" + text + "</" + tag + ">"
+	case "html":
+		return "<" + tag + ">" + text + "</" + tag + ">"
+	}
+	return text
+}
+
+// formatLink formats an external link, titled by its destination if it
+// has no title.
+func formatLink(title, destination string) string {
+	if title == "" {
+		title = destination
+	}
+	switch format {
+	case "markdown":
+		return fmt.Sprintf("[%s](%s)", markdownEscaper.Replace(title), destination)
+	case "html":
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(destination), html.EscapeString(title))
+	}
+	return title
+}
+
+func formatRef(identifier, ptr string) string {
+	name, path, ok := resolveRef(identifier)
+	if !ok {
+		diags.Unresolve(ptr, identifier)
+		if name != "" {
+			return escapeText(name)
+		}
+		return escapeText(fmt.Sprintf("[%s]", path))
+	}
+	switch format {
+	case "markdown":
+		return fmt.Sprintf("[%s](%s)", markdownEscaper.Replace(name), path)
+	case "html":
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(path), html.EscapeString(name))
+	}
+	return name
+}
+
+// parseBlocks converts block content to Blocks. offset is the index of
+// content[0] in the page, for the JSON pointers of diagnostics.
+func parseBlocks(content []docc.BlockContent, ptr string, offset int) (blocks []Block) {
+	for i, b := range content {
+		bptr := fmt.Sprintf("%s/%d", ptr, i+offset)
+		switch b.Type {
+		case "paragraph":
+			blocks = append(blocks, Block{
+				Kind: "paragraph",
+				Text: strings.TrimSpace(parseContent(b.InlineContent, bptr+"/inlineContent")),
+			})
+		case "heading":
+			blocks = append(blocks, Block{Kind: "heading", Text: b.Text, Level: b.Level})
+		case "aside":
+			blocks = append(blocks, Block{
+				Kind:    "aside",
+				Style:   b.Style,
+				Name:    b.Name,
+				Content: parseBlocks(b.Content, bptr+"/content", 0),
+			})
+		case "unorderedList", "orderedList":
+			list := Block{Kind: "list", Ordered: b.Type == "orderedList"}
+			for j, item := range b.Items {
+				list.Items = append(list.Items, parseBlocks(item.Content, fmt.Sprintf("%s/items/%d/content", bptr, j), 0))
+			}
+			blocks = append(blocks, list)
+		case "termList":
+			list := Block{Kind: "termList"}
+			for j, item := range b.Items {
+				iptr := fmt.Sprintf("%s/items/%d", bptr, j)
+				var t Term
+				if item.Term != nil {
+					t.Term = strings.TrimSpace(parseContent(item.Term.InlineContent, iptr+"/term/inlineContent"))
+				}
+				if item.Definition != nil {
+					t.Definition = parseBlocks(item.Definition.Content, iptr+"/definition/content", 0)
+				}
+				list.Terms = append(list.Terms, t)
+			}
+			blocks = append(blocks, list)
+		case "table":
+			table := Block{Kind: "table", Header: b.Header}
+			for r, row := range b.Rows {
+				var cells []Block
+				for c, cell := range row {
+					// cells are block content, but in practice a paragraph
+					text := []string{}
+					for _, cb := range parseBlocks(cell, fmt.Sprintf("%s/rows/%d/%d", bptr, r, c), 0) {
+						text = append(text, cb.Text)
+					}
+					cells = append(cells, Block{Kind: "paragraph", Text: strings.Join(text, " ")})
+				}
+				table.Rows = append(table.Rows, cells)
+			}
+			blocks = append(blocks, table)
+		case "links":
+			list := Block{Kind: "list"}
+			for j, id := range b.Links {
+				text := formatRef(id, fmt.Sprintf("%s/items/%d", bptr, j))
+				list.Items = append(list.Items, []Block{{Kind: "paragraph", Text: text}})
+			}
+			blocks = append(blocks, list)
+		case "codeListing":
+			blocks = append(blocks, Block{Kind: "code", Syntax: b.Syntax, Text: strings.Join(b.Code, "\n")})
+		default:
+			diags.Warn(bptr+"/type", "unknown content block type %q", b.Type)
+		}
+	}
+	return
+}
+
+// resolveRef returns the name and database path of the symbol a reference
+// identifier points to, using the page's references map. Failing that it
+// guesses the path, dropping the numeric IDs of member URLs, and looks for
+// the symbol on disk. If there is no such symbol in the database, ok is
+// false, name is the reference's title if the page has one and path is the
+// best guess.
+func resolveRef(identifier string) (name, path string, ok bool) {
+	if page != nil {
+		if ref, found := page.References[identifier]; found && ref.Title != "" {
+			name = ref.Title
+			if path = docc.PathFromURL(ref.URL); path != "" && inDatabase(path) {
+				return name, path, true
+			}
+		}
+	}
+
+	path = strings.Replace(identifier, "doc://com.apple.documentation/documentation/", "", 1)
 	parts := strings.Split(path, "/")
 	for idx, part := range parts {
 		if idx == 0 {
@@ -292,82 +1220,80 @@ func resolveRefName(identifier string) string {
 			parts[idx] = part[dash+1:]
 		}
 	}
-	symbolfile := fmt.Sprintf("./symbols/%s.json", filepath.Join(parts...))
+	path = filepath.Join(parts...)
+	symbolfile := fmt.Sprintf("./symbols/%s.json", path)
 	s, err := loadData[Symbol](symbolfile)
 	if err != nil {
-		return fmt.Sprintf("[%s]", filepath.Join(parts...))
+		return name, path, false
+	}
+	if name == "" {
+		name = s.Name
 	}
-	return s.Name
+	return name, s.Path, true
 }
 
-func parsePlatforms(platforms any) (plats []Platform) {
-	if platforms == nil {
-		return nil
+func parsePlatforms(platforms []docc.Platform) (plats []Platform) {
+	for _, p := range platforms {
+		plats = append(plats, Platform{
+			Name:         p.Name,
+			IntroducedAt: p.IntroducedAt,
+			Current:      p.Current,
+			Beta:         p.Beta,
+			Deprecated:   p.Deprecated,
+			DeprecatedAt: p.DeprecatedAt,
+		})
 	}
-	for _, p := range platforms.([]any) {
-		current := ""
-		if cur := findPath(p, "/current"); cur != nil {
-			current = cur.(string)
-		}
-		pp := Platform{
-			Name:         findPath(p, "/name").(string),
-			IntroducedAt: findPath(p, "/introducedAt").(string),
-			Current:      current,
-		}
-		if beta := findPath(p, "/beta"); beta != nil {
-			pp.Beta = beta.(bool)
+	return
+}
+
+// parseTokens keeps the declaration tokens, linking those that reference
+// a symbol to its database path.
+func parseTokens(tokens []docc.Token) []Token {
+	var toks []Token
+	for _, t := range tokens {
+		tok := Token{
+			Kind:              t.Kind,
+			Text:              t.Text,
+			PreciseIdentifier: t.PreciseIdentifier,
 		}
-		if deprecated := findPath(p, "/deprecated"); deprecated != nil {
-			pp.Deprecated = deprecated.(bool)
-			deprecatedAt := ""
-			if dep := findPath(p, "/deprecatedAt"); dep != nil {
-				deprecatedAt = dep.(string)
+		if t.Identifier != "" {
+			if _, path, ok := resolveRef(t.Identifier); ok {
+				tok.Path = path
 			}
-			pp.DeprecatedAt = deprecatedAt
 		}
-		plats = append(plats, pp)
+		toks = append(toks, tok)
 	}
-	return
+	return toks
 }
 
-func buildDeclarationFromTokens(tokens any) string {
-	if tokens == nil {
-		return ""
-	}
+func buildDeclarationFromTokens(tokens []docc.Token) string {
 	str := ""
-	for _, t := range tokens.([]any) {
-		str += t.(map[string]any)["text"].(string)
+	for _, t := range tokens {
+		str += t.Text
 	}
 	return str
 }
 
-func findPath(doc any, ptr string) any {
-	p, err := jsonpointer.Parse(ptr)
-	if err != nil {
-		panic(err)
+// writeSymbol writes an inflated symbol, without \u003c escapes so html
+// output stays readable.
+func writeSymbol(path string, s Symbol) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return err
 	}
-	v, err := p.Eval(doc)
-	if err != nil {
-		if strings.Contains(err.Error(), "invalid JSON pointer") {
-			return nil
-		}
-		if strings.Contains(err.Error(), "exceeds array length") {
-			return nil
-		}
-		panic(err)
-	}
-	return v
+	return ioutil.WriteFile(path, bytes.TrimSuffix(buf.Bytes(), []byte("\n")), 0644)
 }
 
-func findWithProp(doc any, key string, value any) any {
-	if doc == nil {
-		return nil
+func writeJSON(filepath string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
 	}
-	for _, obj := range doc.([]any) {
-		v := findPath(obj, "/"+key)
-		if v != nil && reflect.DeepEqual(v, value) {
-			return obj
-		}
+	if err := ioutil.WriteFile(filepath, b, 0644); err != nil {
+		return err
 	}
 	return nil
 }
@@ -0,0 +1,101 @@
+package objc
+
+import (
+	"strings"
+	"unicode"
+)
+
+// return ownership annotations, as macros and as attributes
+var returnOwnerships = map[string]string{
+	"NS_RETURNS_RETAINED": "retained", "CF_RETURNS_RETAINED": "retained",
+	"ns_returns_retained": "retained", "cf_returns_retained": "retained",
+	"NS_RETURNS_NOT_RETAINED": "not_retained", "CF_RETURNS_NOT_RETAINED": "not_retained",
+	"ns_returns_not_retained": "not_retained", "cf_returns_not_retained": "not_retained",
+	"NS_RETURNS_INNER_POINTER": "inner_pointer", "objc_returns_inner_pointer": "inner_pointer",
+}
+
+// annotations of parameters whose ownership passes to the callee
+var consumedAnnotations = map[string]bool{
+	"NS_CONSUMED": true, "CF_CONSUMED": true, "NS_RELEASES_ARGUMENT": true, "CF_RELEASES_ARGUMENT": true,
+	"ns_consumed": true, "cf_consumed": true,
+}
+
+// annotate sets the fields of t derived from its qualifiers. The
+// nullability of the outermost pointer is spelled last.
+func (t *Type) annotate() {
+	t.Nullability, t.KindOf = "", false
+	for _, q := range t.Qualifiers {
+		if n := nullabilities[q]; n != "" {
+			t.Nullability = n
+		}
+		if q == "__kindof" {
+			t.KindOf = true
+		}
+	}
+}
+
+// annotated reports whether any of the names or __attribute__ lists has
+// one of the annotations.
+func annotated(names []string, annotations map[string]bool) bool {
+	for _, name := range names {
+		for _, word := range attributeWords(name) {
+			if annotations[word] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// returnOwnership returns the ownership of a returned object declared by
+// a return type qualifier or attribute.
+func returnOwnership(names ...[]string) string {
+	for _, list := range names {
+		for _, name := range list {
+			for _, word := range attributeWords(name) {
+				if o := returnOwnerships[word]; o != "" {
+					return o
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// attributeWords splits a macro or __attribute__((a, b(c))) into its
+// words.
+func attributeWords(attr string) []string {
+	return strings.FieldsFunc(attr, func(r rune) bool {
+		return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// createRule reports whether a function returning ret follows the
+// CoreFoundation Create Rule: the caller owns objects returned by
+// functions with Create or Copy in their name.
+func createRule(name string, ret Type) bool {
+	if !isCFType(ret) {
+		return false
+	}
+	for _, word := range []string{"Create", "Copy"} {
+		for i := 0; i < len(name); {
+			j := strings.Index(name[i:], word)
+			if j < 0 {
+				break
+			}
+			end := i + j + len(word)
+			if end == len(name) || !unicode.IsLower(rune(name[end])) {
+				return true
+			}
+			i = end
+		}
+	}
+	return false
+}
+
+// isCFType reports whether t is a CoreFoundation-style object reference,
+// like CFStringRef or CGColorRef.
+func isCFType(t Type) bool {
+	return t.Pointers == 0 && t.Array == nil && t.Func == nil &&
+		(strings.HasSuffix(t.Base, "Ref") || t.Base == "CFTypeRef")
+}
@@ -0,0 +1,82 @@
+package objc
+
+import "testing"
+
+func TestFunctionReturnOwnership(t *testing.T) {
+	tests := []struct {
+		decl       string
+		ownership  string
+		createRule bool
+	}{
+		{"CFStringRef CFStringCreateCopy(CFAllocatorRef alloc, CFStringRef theString);", "retained", true},
+		{"CFArrayRef CFArrayCreate(CFAllocatorRef allocator, const void **values, CFIndex numValues, const CFArrayCallBacks *callBacks);", "retained", true},
+		{"CFStringRef CFCopyDescription(CFTypeRef cf);", "retained", true},
+		// the Get Rule
+		{"const void *CFArrayGetValueAtIndex(CFArrayRef theArray, CFIndex idx);", "", false},
+		{"CFStringRef CFDictionaryGetValue(CFDictionaryRef theDict, const void *key);", "", false},
+		// Create and Copy are whole words
+		{"CFStringRef CFCreatedString(void);", "", false},
+		{"CFStringRef CFCopyrightNotice(void);", "", false},
+		// only CoreFoundation-style types
+		{"void *CFCreateBuffer(size_t size);", "", false},
+		// annotations override the naming rules
+		{"CFStringRef CFStringGetDefault(void) CF_RETURNS_RETAINED;", "retained", false},
+		{"CF_RETURNS_NOT_RETAINED CFStringRef CFStringCreateShared(void);", "not_retained", false},
+		{"CFTypeRef CFMakeCollectable(CFTypeRef cf) __attribute__((cf_returns_retained));", "retained", false},
+	}
+	for _, test := range tests {
+		f, err := ParseFunction(test.decl)
+		if err != nil {
+			t.Errorf("ParseFunction(%q): %v", test.decl, err)
+			continue
+		}
+		if f.ReturnOwnership != test.ownership || f.CreateRule != test.createRule {
+			t.Errorf("ParseFunction(%q) ownership %q, create rule %t, want %q, %t", test.decl, f.ReturnOwnership, f.CreateRule, test.ownership, test.createRule)
+		}
+	}
+}
+
+func TestMethodAnnotations(t *testing.T) {
+	tests := []struct {
+		decl        string
+		ownership   string
+		consumed    bool
+		consumes    bool
+		designated  bool
+		nullability string
+	}{
+		{"- (nullable NSString *)name;", "", false, false, false, "nullable"},
+		{"- (NSString * _Nonnull)name;", "", false, false, false, "nonnull"},
+		{"- (id)retain NS_RETURNS_RETAINED;", "retained", false, false, false, ""},
+		{"- (const char *)UTF8String NS_RETURNS_INNER_POINTER;", "inner_pointer", false, false, false, ""},
+		{"- (void)setObject:(id) NS_CONSUMED obj;", "", true, false, false, ""},
+		{"- (instancetype)initWithCoder:(NSCoder *)coder NS_DESIGNATED_INITIALIZER NS_CONSUMES_SELF;", "", false, true, true, ""},
+	}
+	for _, test := range tests {
+		m, err := ParseMethod(test.decl)
+		if err != nil {
+			t.Errorf("ParseMethod(%q): %v", test.decl, err)
+			continue
+		}
+		consumed := false
+		for _, arg := range m.Args {
+			consumed = consumed || arg.Consumed
+		}
+		if m.ReturnOwnership != test.ownership || consumed != test.consumed || m.ConsumesSelf != test.consumes ||
+			m.DesignatedInitializer != test.designated || m.Return.Nullability != test.nullability {
+			t.Errorf("ParseMethod(%q) = ownership %q, consumed %t, consumes self %t, designated %t, nullability %q; want %q, %t, %t, %t, %q",
+				test.decl, m.ReturnOwnership, consumed, m.ConsumesSelf, m.DesignatedInitializer, m.Return.Nullability,
+				test.ownership, test.consumed, test.consumes, test.designated, test.nullability)
+		}
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	prop, err := ParseProperty("@property (readonly) __kindof NSView * _Nullable contentView;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !prop.Type.KindOf || prop.Nullability != "nullable" {
+		t.Errorf("contentView type %+v, nullability %q, want __kindof and nullable", prop.Type, prop.Nullability)
+	}
+}
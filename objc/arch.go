@@ -0,0 +1,124 @@
+package objc
+
+import "strings"
+
+// Archs are the architectures Primitives describes.
+var Archs = []string{"arm64", "x86_64", "i386", "armv7"}
+
+// Primitive is the layout of a C arithmetic type on an architecture.
+type Primitive struct {
+	Type   string // canonical spelling: long, unsigned char, double, ...
+	Size   int
+	Align  int
+	Signed bool `json:",omitempty"`
+	Float  bool `json:",omitempty"`
+}
+
+// typedefs whose definition depends on the architecture, by typedef name
+// and architecture. Documentation shows the 64-bit definition.
+var archTypedefs = map[string]map[string]string{
+	"CGFloat":    {"i386": "float", "armv7": "float"},
+	"NSInteger":  {"i386": "int", "armv7": "int"},
+	"NSUInteger": {"i386": "unsigned int", "armv7": "unsigned int"},
+	"BOOL":       {"arm64": "bool", "x86_64": "signed char", "i386": "signed char", "armv7": "signed char"},
+}
+
+// type sizes on LP64 and ILP32 architectures
+var (
+	lp64 = map[string][2]int{
+		"char": {1, 1}, "short": {2, 2}, "int": {4, 4}, "long": {8, 8}, "long long": {8, 8},
+		"float": {4, 4}, "double": {8, 8}, "bool": {1, 1}, "__int128": {16, 16},
+	}
+	ilp32 = map[string][2]int{
+		"char": {1, 1}, "short": {2, 2}, "int": {4, 4}, "long": {4, 4}, "long long": {8, 8},
+		"float": {4, 4}, "double": {8, 8}, "bool": {1, 1},
+	}
+	longDouble = map[string][2]int{
+		"arm64": {8, 8}, "x86_64": {16, 16}, "i386": {12, 4}, "armv7": {8, 8},
+	}
+)
+
+// PointerSize returns the size of pointers on arch.
+func PointerSize(arch string) int {
+	if arch == "arm64" || arch == "x86_64" {
+		return 8
+	}
+	return 4
+}
+
+// Layout returns the layout of the arithmetic type spelled base on arch.
+func Layout(base, arch string) (Primitive, bool) {
+	prim := Primitive{Type: base, Signed: true}
+	var words []string
+	for _, w := range strings.Fields(base) {
+		switch w {
+		case "unsigned":
+			prim.Signed = false
+		case "signed", "int":
+			// int is implied by the other words, if any
+		case "_Bool":
+			words = append(words, "bool")
+		default:
+			words = append(words, w)
+		}
+	}
+	name := strings.Join(words, " ")
+	if name == "" {
+		name = "int"
+	}
+	switch name {
+	case "bool":
+		prim.Signed = false
+	case "float", "double", "long double":
+		prim.Float = true
+	case "void":
+		return Primitive{}, false
+	}
+	if name == "long double" {
+		sz := longDouble[arch]
+		prim.Size, prim.Align = sz[0], sz[1]
+		return prim, sz[0] > 0
+	}
+	sizes := ilp32
+	if PointerSize(arch) == 8 {
+		sizes = lp64
+	}
+	sz, ok := sizes[name]
+	if !ok {
+		return Primitive{}, false
+	}
+	prim.Size, prim.Align = sz[0], sz[1]
+	return prim, true
+}
+
+// Primitives returns the primitive a type resolves to on each
+// architecture. names are the typedef names the type was resolved
+// through, in order, and canonical the type they resolve to. It returns
+// nil if the type is not arithmetic.
+func Primitives(names []string, canonical Type) map[string]Primitive {
+	if canonical.Pointers > 0 || canonical.Array != nil {
+		return nil
+	}
+	prims := make(map[string]Primitive)
+	for _, arch := range Archs {
+		base := canonical.Base
+		for _, name := range names {
+			if t, ok := archTypedefs[name][arch]; ok {
+				base = t
+				break
+			} else if _, ok := archTypedefs[name]; ok {
+				break
+			}
+		}
+		if base == "" {
+			continue
+		}
+		if prim, ok := Layout(base, arch); ok {
+			prims[arch] = prim
+		}
+	}
+	if len(prims) == 0 {
+		return nil
+	}
+	return prims
+}
@@ -0,0 +1,56 @@
+package objc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrimitives(t *testing.T) {
+	long := Primitive{Type: "long", Size: 8, Align: 8, Signed: true}
+	tests := []struct {
+		names     []string
+		canonical Type
+		want      map[string]Primitive
+	}{
+		{
+			[]string{"NSInteger"},
+			Type{Base: "long"},
+			map[string]Primitive{
+				"arm64":  long,
+				"x86_64": long,
+				"i386":   {Type: "int", Size: 4, Align: 4, Signed: true},
+				"armv7":  {Type: "int", Size: 4, Align: 4, Signed: true},
+			},
+		},
+		{
+			[]string{"NSModalResponse", "NSInteger"},
+			Type{Base: "long"},
+			map[string]Primitive{
+				"arm64":  long,
+				"x86_64": long,
+				"i386":   {Type: "int", Size: 4, Align: 4, Signed: true},
+				"armv7":  {Type: "int", Size: 4, Align: 4, Signed: true},
+			},
+		},
+		{
+			[]string{"CGFloat"},
+			Type{Base: "double"},
+			map[string]Primitive{
+				"arm64":  {Type: "double", Size: 8, Align: 8, Signed: true, Float: true},
+				"x86_64": {Type: "double", Size: 8, Align: 8, Signed: true, Float: true},
+				"i386":   {Type: "float", Size: 4, Align: 4, Signed: true, Float: true},
+				"armv7":  {Type: "float", Size: 4, Align: 4, Signed: true, Float: true},
+			},
+		},
+		// pointer typedefs aren't arithmetic, even through one that is
+		{[]string{"NSIntegerPointer", "NSInteger"}, Type{Base: "long", Pointers: 1}, nil},
+		{[]string{"CFStringRef"}, Type{Base: "struct __CFString", Pointers: 1}, nil},
+		{[]string{"NSPoint"}, Type{Base: "struct CGPoint"}, nil},
+		{[]string{"uuid_t"}, Type{Base: "unsigned char", Array: []string{"16"}}, nil},
+	}
+	for _, test := range tests {
+		if got := Primitives(test.names, test.canonical); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Primitives(%q, %+v) =\n%+v\nwant\n%+v", test.names, test.canonical, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package objc
+
+import "strings"
+
+// parseDeclarator parses the declarator following the specifiers spec,
+// spelled from token specStart up to the current position. The name is
+// optional, so abstract declarators of parameter types parse too. The
+// returned type is spelled without the name.
+func (p *parser) parseDeclarator(spec Type, specStart, specEnd int) (string, Type, error) {
+	t := spec
+	t.Qualifiers = append([]string(nil), spec.Qualifiers...)
+	start := p.pos
+	p.parsePointers(&t)
+
+	name, nameIdx := "", -1
+	if p.atFuncDeclarator() {
+		// block or function pointer: (^name)(params) or (*name)(params)
+		ret := t
+		ret.Spelling = strings.TrimSpace(p.spelling(specStart, specEnd) + " " + p.spelling(start, p.pos))
+		ret.annotate()
+		f := &FuncType{}
+		f.Return, f.Escaping = withoutNoescape(ret)
+		p.next()
+		for {
+			if tok := p.next(); tok.is("^") || tok.is("*") {
+				f.Block = f.Block || tok.is("^")
+				continue
+			} else if tok.kind == tokIdent && qualifierWords[tok.text] {
+				if n := nullabilities[tok.text]; n != "" {
+					f.Nullability = n
+				}
+				if tok.text == "NS_NOESCAPE" || tok.text == "CF_NOESCAPE" {
+					f.Escaping = false
+				}
+				continue
+			}
+			p.pos--
+			break
+		}
+		f.Escaping = f.Escaping && f.Block
+		if tok := p.peek(); tok.kind == tokIdent {
+			name, nameIdx = tok.text, p.pos
+			p.next()
+		}
+		if err := p.expect(")"); err != nil {
+			return "", t, err
+		}
+		if err := p.expect("("); err != nil {
+			return "", t, err
+		}
+		var err error
+		if f.Params, f.Variadic, err = p.parseParams(); err != nil {
+			return "", t, err
+		}
+		t = Type{Func: f}
+	} else if tok := p.peek(); tok.kind == tokIdent && !qualifierWords[tok.text] {
+		name, nameIdx = tok.text, p.pos
+		p.next()
+	}
+
+	for p.accept("[") {
+		n := p.pos
+		end, err := p.matching("]")
+		if err != nil {
+			return "", t, err
+		}
+		t.Array = append(t.Array, p.spelling(n, end))
+		p.pos = end + 1
+	}
+
+	t.Spelling = strings.TrimSpace(p.spelling(specStart, specEnd) + " " + p.spellingExcept(start, p.pos, nameIdx))
+	t.annotate()
+	return name, t, nil
+}
+
+// atFuncDeclarator reports whether a block or function pointer declarator
+// follows: a parenthesis, then qualifiers, then ^ or *.
+func (p *parser) atFuncDeclarator() bool {
+	if !p.peek().is("(") {
+		return false
+	}
+	for i := 1; ; i++ {
+		switch tok := p.peekN(i); {
+		case tok.is("^") || tok.is("*"):
+			return true
+		case tok.kind != tokIdent || !qualifierWords[tok.text]:
+			return false
+		}
+	}
+}
+
+// withoutNoescape removes NS_NOESCAPE from the qualifiers of a block's
+// return type, where the specifiers of a parameter put it, and reports
+// whether a block would escape.
+func withoutNoescape(ret Type) (Type, bool) {
+	escaping := true
+	var qs []string
+	for _, q := range ret.Qualifiers {
+		if q == "NS_NOESCAPE" || q == "CF_NOESCAPE" {
+			escaping = false
+			continue
+		}
+		qs = append(qs, q)
+	}
+	ret.Qualifiers = qs
+	if !escaping {
+		ret.Spelling = strings.TrimSpace(strings.NewReplacer("NS_NOESCAPE", "", "CF_NOESCAPE", "").Replace(ret.Spelling))
+	}
+	return ret, escaping
+}
+
+// spellingExcept is spelling without the token at skip, if any.
+func (p *parser) spellingExcept(from, to, skip int) string {
+	if skip < from || skip >= to {
+		return p.spelling(from, to)
+	}
+	left, right := p.spelling(from, skip), p.spelling(skip+1, to)
+	if left == "" || right == "" || strings.HasSuffix(left, "(") ||
+		strings.HasPrefix(right, ")") || strings.HasPrefix(right, "[") {
+		return left + right
+	}
+	return left + " " + right
+}
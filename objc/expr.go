@@ -0,0 +1,783 @@
+package objc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrNotNumeric is returned by Eval for expressions that are valid but
+// have no numeric value, like string literals.
+var ErrNotNumeric = errors.New("objc: not a numeric expression")
+
+// Value is the value of a constant expression. Integers are in the
+// two's complement range of Width bits.
+type Value struct {
+	Number json.Number
+	Signed bool
+	Width  int  // bits
+	Float  bool `json:",omitempty"`
+}
+
+// Scope resolves the names in a constant expression.
+type Scope struct {
+	// Const returns the value of a named constant.
+	Const func(name string) (Value, bool)
+	// Type returns the width in bits and signedness of an integer or
+	// floating type, for casts.
+	Type func(t Type) (width int, signed, float bool, ok bool)
+}
+
+// limits of the standard and Foundation integer types, for macros that
+// refer to them
+var limits = map[string]num{
+	"CHAR_MAX": {u: math.MaxInt8, signed: true, width: 32}, "SCHAR_MAX": {u: math.MaxInt8, signed: true, width: 32},
+	"UCHAR_MAX": {u: math.MaxUint8, signed: true, width: 32},
+	"SHRT_MAX":  {u: math.MaxInt16, signed: true, width: 32}, "USHRT_MAX": {u: math.MaxUint16, signed: true, width: 32},
+	"INT_MAX": {u: math.MaxInt32, signed: true, width: 32}, "UINT_MAX": {u: math.MaxUint32, width: 32},
+	"LONG_MAX": {u: math.MaxInt64, signed: true, width: 64}, "ULONG_MAX": {u: math.MaxUint64, width: 64},
+	"LLONG_MAX": {u: math.MaxInt64, signed: true, width: 64}, "ULLONG_MAX": {u: math.MaxUint64, width: 64},
+	"INT8_MAX": {u: math.MaxInt8, signed: true, width: 32}, "UINT8_MAX": {u: math.MaxUint8, signed: true, width: 32},
+	"INT16_MAX": {u: math.MaxInt16, signed: true, width: 32}, "UINT16_MAX": {u: math.MaxUint16, signed: true, width: 32},
+	"INT32_MAX": {u: math.MaxInt32, signed: true, width: 32}, "UINT32_MAX": {u: math.MaxUint32, width: 32},
+	"INT64_MAX": {u: math.MaxInt64, signed: true, width: 64}, "UINT64_MAX": {u: math.MaxUint64, width: 64},
+	"NSIntegerMax": {u: math.MaxInt64, signed: true, width: 64}, "NSUIntegerMax": {u: math.MaxUint64, width: 64},
+	"INT_MIN": {u: 1 << 31, signed: true, width: 32}, "LONG_MIN": {u: 1 << 63, signed: true, width: 64},
+	"INT32_MIN": {u: 1 << 31, signed: true, width: 32}, "INT64_MIN": {u: 1 << 63, signed: true, width: 64},
+	"NSIntegerMin": {u: 1 << 63, signed: true, width: 64},
+	"YES":          {u: 1, signed: true, width: 32}, "NO": {signed: true, width: 32},
+	"true": {u: 1, signed: true, width: 32}, "false": {signed: true, width: 32},
+}
+
+// Constant is a parsed constant declaration: an enumeration case
+// (NSWindowStyleMaskTitled = 1 << 0), a variable (static const CGFloat
+// kFoo = 1.0;) or an object-like macro (#define NSFoo 4). Expr is empty
+// when no value is declared, and Type when none is spelled.
+type Constant struct {
+	Name string
+	Type *Type `json:",omitempty"`
+	Expr string
+}
+
+// ParseConstant parses a constant declaration.
+func ParseConstant(decl string) (*Constant, error) {
+	p, err := newParser(decl)
+	if err != nil {
+		return nil, err
+	}
+	c := &Constant{}
+	switch {
+	case p.peek().is("#"):
+		p.next()
+		if err := p.expect("define"); err != nil {
+			return nil, err
+		}
+		name := p.next()
+		if name.kind != tokIdent {
+			return nil, p.errorf("expected macro name")
+		}
+		c.Name = name.text
+		if p.peek().is("(") && p.peek().start == name.end {
+			// a function-like macro has no value
+			return c, nil
+		}
+	case p.peek().is("enum") && (p.peekN(1).is("{") || p.peekN(1).is(":")):
+		return parseEnumConstant(p)
+	case p.peek().kind == tokIdent && (p.peekN(1).is("=") || p.peekN(1).kind == tokEOF || p.peekN(1).is(",")):
+		c.Name = p.next().text
+		if !p.accept("=") {
+			return c, nil
+		}
+	default:
+		for p.peek().kind == tokIdent && isLinkage(p.peek().text) {
+			p.next()
+		}
+		specStart := p.pos
+		spec, err := p.parseSpecifiers()
+		if err != nil {
+			return nil, err
+		}
+		name, t, err := p.parseDeclarator(spec, specStart, p.pos)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			return nil, p.errorf("expected constant name")
+		}
+		c.Name, c.Type = name, &t
+		if !p.accept("=") {
+			if _, err := p.parseAttributes(); err != nil {
+				return nil, err
+			}
+			p.accept(";")
+			if !p.atEnd() {
+				return nil, p.errorf("unexpected token")
+			}
+			return c, nil
+		}
+	}
+
+	start := p.pos
+	if _, err := p.parseExpr(); err != nil {
+		return nil, err
+	}
+	c.Expr = p.spelling(start, p.pos)
+	if _, err := p.parseAttributes(); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	p.accept(",")
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected token")
+	}
+	return c, nil
+}
+
+// parseEnumConstant parses an anonymous enumeration declaring a single
+// case, enum { kFoo = 1 };, as a constant of its underlying type.
+func parseEnumConstant(p *parser) (*Constant, error) {
+	p.next()
+	t := Type{Spelling: "int", Base: "int"}
+	if p.accept(":") {
+		var err error
+		if t, err = p.parseType(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokIdent {
+		return nil, p.errorf("expected enumeration case")
+	}
+	c := &Constant{Name: p.next().text, Type: &t}
+	if p.accept("=") {
+		start := p.pos
+		if _, err := p.parseExpr(); err != nil {
+			return nil, err
+		}
+		c.Expr = p.spelling(start, p.pos)
+	}
+	if _, err := p.parseAttributes(); err != nil {
+		return nil, err
+	}
+	p.accept(",")
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected token")
+	}
+	return c, nil
+}
+
+// Eval evaluates a constant expression.
+func Eval(expr string, scope Scope) (Value, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return Value{}, err
+	}
+	p.scope = scope
+	e, err := p.parseExpr()
+	if err != nil {
+		return Value{}, err
+	}
+	if !p.atEnd() {
+		return Value{}, p.errorf("unexpected token")
+	}
+	n, err := e.eval(scope)
+	if err != nil {
+		return Value{}, err
+	}
+	return n.value(), nil
+}
+
+// Convert converts a value to an integer or floating type of the given
+// width, the way C converts the initializer of a constant or enumeration
+// case to its type.
+func (v Value) Convert(width int, signed, float bool) Value {
+	n, err := numOf(v)
+	if err != nil {
+		return v
+	}
+	return n.to(width, signed, float).value()
+}
+
+// Next returns the value following v, the implicit value of an
+// enumeration case declared without one after the case of value v.
+func (v Value) Next() Value {
+	n, err := numOf(v)
+	if err != nil || v.Float {
+		return v
+	}
+	n.u++
+	return n.norm().value()
+}
+
+// num is an intermediate value: the bits of an integer of width bits, or
+// a float.
+type num struct {
+	u      uint64
+	f      float64
+	float  bool
+	signed bool
+	width  int
+}
+
+func (n num) norm() num {
+	if !n.float && n.width < 64 {
+		mask := uint64(1)<<n.width - 1
+		n.u &= mask
+		if n.signed && n.u&(1<<(n.width-1)) != 0 {
+			n.u |= ^mask
+		}
+	}
+	return n
+}
+
+func (n num) int() int64 {
+	return int64(n.u)
+}
+
+func (n num) toFloat() float64 {
+	switch {
+	case n.float:
+		return n.f
+	case n.signed:
+		return float64(n.int())
+	}
+	return float64(n.u)
+}
+
+func (n num) isTrue() bool {
+	if n.float {
+		return n.f != 0
+	}
+	return n.u != 0
+}
+
+func (n num) value() Value {
+	v := Value{Signed: n.signed, Width: n.width, Float: n.float}
+	switch {
+	case n.float:
+		v.Number = json.Number(strconv.FormatFloat(n.f, 'g', -1, 64))
+	case n.signed:
+		v.Number = json.Number(strconv.FormatInt(n.int(), 10))
+	default:
+		v.Number = json.Number(strconv.FormatUint(n.u, 10))
+	}
+	return v
+}
+
+func numOf(v Value) (num, error) {
+	n := num{signed: v.Signed, width: v.Width, float: v.Float}
+	var err error
+	switch {
+	case v.Float:
+		n.f, err = strconv.ParseFloat(string(v.Number), 64)
+	case v.Signed:
+		var i int64
+		i, err = strconv.ParseInt(string(v.Number), 10, 64)
+		n.u = uint64(i)
+	default:
+		n.u, err = strconv.ParseUint(string(v.Number), 10, 64)
+	}
+	if n.width == 0 {
+		n.width = 64
+	}
+	return n, err
+}
+
+func boolNum(b bool) num {
+	n := num{signed: true, width: 32}
+	if b {
+		n.u = 1
+	}
+	return n
+}
+
+// expr is a node of a parsed constant expression.
+type expr interface {
+	eval(scope Scope) (num, error)
+}
+
+type (
+	litExpr    num
+	stringExpr string
+	identExpr  string
+	castExpr   struct {
+		t Type
+		x expr
+	}
+	unaryExpr struct {
+		op string
+		x  expr
+	}
+	binaryExpr struct {
+		op   string
+		x, y expr
+	}
+	condExpr struct {
+		cond, x, y expr
+	}
+)
+
+// binary operator precedences, higher binds tighter
+var precedence = map[string]int{
+	"||": 1, "&&": 2, "|": 3, "^": 4, "&": 5,
+	"==": 6, "!=": 6, "<": 7, ">": 7, "<=": 7, ">=": 7,
+	"<<": 8, ">>": 8, "+": 9, "-": 9, "*": 10, "/": 10, "%": 10,
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	cond, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return cond, nil
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	y, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return condExpr{cond, x, y}, nil
+}
+
+func (p *parser) parseBinary(min int) (expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, n := p.peekOperator()
+		prec := precedence[op.text]
+		if op.kind != tokPunct || prec < min {
+			return x, nil
+		}
+		p.pos += n
+		y, err := p.parseBinary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = binaryExpr{op.text, x, y}
+	}
+}
+
+// peekOperator returns the operator at the current position and the
+// number of tokens spelling it, joining >> and >= which lex as single
+// characters.
+func (p *parser) peekOperator() (token, int) {
+	op, n := p.peek(), 1
+	if !op.is(">") {
+		return op, n
+	}
+	for _, c := range []string{">", "="} {
+		if next := p.peekN(n); next.is(c) && next.start == op.end && op.text != ">=" {
+			op.text += c
+			op.end = next.end
+			n++
+		}
+	}
+	return op, n
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	t := p.peek()
+	switch {
+	case t.is("-") || t.is("+") || t.is("~") || t.is("!"):
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpr{t.text, x}, nil
+	case t.is("("):
+		// (name) - x is a subtraction if name is a constant, or else a
+		// cast of -x
+		save := p.pos
+		if typ, ok := p.parseCast(); ok {
+			if !p.isConst(typ) {
+				x, err := p.parseUnary()
+				if err != nil {
+					return nil, err
+				}
+				return castExpr{typ, x}, nil
+			}
+			p.pos = save
+		}
+		p.next()
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return x, nil
+	case t.kind == tokNumber:
+		p.next()
+		n, err := parseNumber(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("objc: %v at %d", err, t.start)
+		}
+		return litExpr(n), nil
+	case t.kind == tokChar:
+		p.next()
+		n, err := parseChar(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("objc: %v at %d", err, t.start)
+		}
+		return litExpr(n), nil
+	case t.kind == tokString:
+		p.next()
+		// adjacent literals concatenate
+		for p.peek().kind == tokString {
+			p.next()
+		}
+		return stringExpr(t.text), nil
+	case t.kind == tokIdent && !(p.peekN(1).is("(") && p.peekN(1).start == t.end):
+		p.next()
+		return identExpr(t.text), nil
+	}
+	return nil, p.errorf("expected expression")
+}
+
+// parseCast parses a parenthesized type name followed by an operand.
+func (p *parser) parseCast() (Type, bool) {
+	save := p.pos
+	p.next()
+	end, err := p.matching(")")
+	if err != nil {
+		p.pos = save
+		return Type{}, false
+	}
+	sub := &parser{src: p.src, toks: p.toks[p.pos:end]}
+	t, err := sub.parseType()
+	// a parenthesized lone name is a cast only if an operand follows
+	next := p.toks[end+1:]
+	operand := len(next) > 0 && (next[0].kind != tokPunct || next[0].is("(") || next[0].is("~") || next[0].is("!") ||
+		next[0].is("-") || next[0].is("+"))
+	if err != nil || !sub.atEnd() || (len(sub.toks) == 1 && !builtinWords[t.Base] && !operand) {
+		p.pos = save
+		return Type{}, false
+	}
+	p.pos = end + 1
+	return t, true
+}
+
+// isConst reports whether the type of a cast is a lone name that is a
+// constant in the scope of the expression, so not a type.
+func (p *parser) isConst(t Type) bool {
+	if p.scope.Const == nil || t.Pointers > 0 || t.Qualifiers != nil || t.Args != nil || t.Protocols != nil {
+		return false
+	}
+	_, ok := p.scope.Const(t.Base)
+	return ok
+}
+
+// parseNumber parses an integer or floating literal with its suffixes.
+func parseNumber(s string) (num, error) {
+	lower := strings.ToLower(s)
+	hex := strings.HasPrefix(lower, "0x")
+	if !hex && strings.ContainsAny(lower, ".e") || hex && strings.Contains(lower, "p") {
+		f, err := strconv.ParseFloat(strings.TrimRight(lower, "fl"), 64)
+		return num{f: f, float: true, signed: true, width: 64}, err
+	}
+	digits := strings.TrimRight(lower, "ul")
+	suffix := lower[len(digits):]
+	base := 10
+	switch {
+	case hex:
+		base, digits = 16, digits[2:]
+	case strings.HasPrefix(digits, "0b"):
+		base, digits = 2, digits[2:]
+	case len(digits) > 1 && digits[0] == '0':
+		base, digits = 8, digits[1:]
+	}
+	u, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		return num{}, fmt.Errorf("bad number %q", s)
+	}
+	n := num{u: u, signed: !strings.Contains(suffix, "u"), width: 32}
+	if strings.Contains(suffix, "l") {
+		n.width = 64
+	}
+	// the first type of int, unsigned int (not for decimals), long,
+	// unsigned long that fits
+	switch {
+	case n.width == 32 && n.signed && u <= math.MaxInt32:
+	case n.width == 32 && u <= math.MaxUint32 && (base != 10 || !n.signed):
+		n.signed = false
+	case n.signed && u <= math.MaxInt64:
+		n.width = 64
+	default:
+		n.width, n.signed = 64, false
+	}
+	return n, nil
+}
+
+// parseChar parses a character literal. Multi-character literals like
+// 'abcd' are four-char codes, the characters packed big-endian into an
+// int.
+func parseChar(s string) (num, error) {
+	body, err := strconv.Unquote(`"` + strings.ReplaceAll(s[1:len(s)-1], `"`, `\"`) + `"`)
+	if err != nil || body == "" || len(body) > 4 {
+		return num{}, fmt.Errorf("bad character literal %s", s)
+	}
+	var u uint64
+	for i := 0; i < len(body); i++ {
+		u = u<<8 | uint64(body[i])
+	}
+	if len(body) == 1 && body[0] >= 0x80 {
+		// char is signed
+		u |= ^uint64(0xff)
+	}
+	return num{u: u, signed: true, width: 32}.norm(), nil
+}
+
+func (e litExpr) eval(Scope) (num, error) {
+	return num(e), nil
+}
+
+func (e stringExpr) eval(Scope) (num, error) {
+	return num{}, ErrNotNumeric
+}
+
+func (e identExpr) eval(scope Scope) (num, error) {
+	if scope.Const != nil {
+		if v, ok := scope.Const(string(e)); ok {
+			return numOf(v)
+		}
+	}
+	if n, ok := limits[string(e)]; ok {
+		return n.norm(), nil
+	}
+	return num{}, fmt.Errorf("objc: unknown constant %s", string(e))
+}
+
+func (e castExpr) eval(scope Scope) (num, error) {
+	x, err := e.x.eval(scope)
+	if err != nil {
+		return num{}, err
+	}
+	var width int
+	var signed, float, ok bool
+	if e.t.Pointers == 0 && e.t.Array == nil {
+		if scope.Type != nil {
+			width, signed, float, ok = scope.Type(e.t)
+		}
+		if !ok {
+			if prim, found := Layout(e.t.Base, "arm64"); found {
+				width, signed, float, ok = prim.Size*8, prim.Signed, prim.Float, true
+			}
+		}
+	}
+	if !ok {
+		return num{}, fmt.Errorf("objc: can't cast to %s", e.t.Spelling)
+	}
+	return x.to(width, signed, float), nil
+}
+
+// to converts n to an integer or floating type, truncating floating
+// values converted to integers.
+func (n num) to(width int, signed, float bool) num {
+	switch {
+	case float:
+		f := n.toFloat()
+		if width == 32 {
+			f = float64(float32(f))
+		}
+		return num{f: f, float: true, signed: true, width: width}
+	case n.float:
+		f := n.f
+		n = num{u: uint64(int64(f))}
+		if !signed && f >= 0 {
+			n.u = uint64(f)
+		}
+	}
+	n.width, n.signed = width, signed
+	return n.norm()
+}
+
+func (e unaryExpr) eval(scope Scope) (num, error) {
+	x, err := e.x.eval(scope)
+	if err != nil {
+		return num{}, err
+	}
+	x = promote(x)
+	switch e.op {
+	case "-":
+		if x.float {
+			x.f = -x.f
+		} else {
+			x.u = -x.u
+		}
+	case "~":
+		if x.float {
+			return num{}, fmt.Errorf("objc: ~ of a floating value")
+		}
+		x.u = ^x.u
+	case "!":
+		return boolNum(!x.isTrue()), nil
+	}
+	return x.norm(), nil
+}
+
+func (e condExpr) eval(scope Scope) (num, error) {
+	cond, err := e.cond.eval(scope)
+	if err != nil {
+		return num{}, err
+	}
+	if cond.isTrue() {
+		return e.x.eval(scope)
+	}
+	return e.y.eval(scope)
+}
+
+// promote applies the integer promotions.
+func promote(x num) num {
+	if !x.float && x.width < 32 {
+		x.width, x.signed = 32, true
+	}
+	return x
+}
+
+// convert applies the usual arithmetic conversions to a pair of operands.
+func convert(x, y num) (num, num) {
+	x, y = promote(x), promote(y)
+	switch {
+	case x.float || y.float:
+		return num{f: x.toFloat(), float: true, signed: true, width: 64},
+			num{f: y.toFloat(), float: true, signed: true, width: 64}
+	case x.width == y.width:
+		signed := x.signed && y.signed
+		x.signed, y.signed = signed, signed
+	case x.width > y.width:
+		y.width, y.signed = x.width, x.signed
+	default:
+		x.width, x.signed = y.width, y.signed
+	}
+	return x.norm(), y.norm()
+}
+
+func (e binaryExpr) eval(scope Scope) (num, error) {
+	x, err := e.x.eval(scope)
+	if err != nil {
+		return num{}, err
+	}
+	// short circuit
+	switch {
+	case e.op == "&&" && !x.isTrue():
+		return boolNum(false), nil
+	case e.op == "||" && x.isTrue():
+		return boolNum(true), nil
+	}
+	y, err := e.y.eval(scope)
+	if err != nil {
+		return num{}, err
+	}
+	switch e.op {
+	case "&&", "||":
+		return boolNum(y.isTrue()), nil
+	case "<<", ">>":
+		x, y = promote(x), promote(y)
+		if x.float || y.float {
+			return num{}, fmt.Errorf("objc: %s of a floating value", e.op)
+		}
+		shift := y.u
+		if y.signed && y.int() < 0 || shift >= uint64(x.width) {
+			return num{}, fmt.Errorf("objc: shift by %d out of range", y.int())
+		}
+		if e.op == "<<" {
+			x.u <<= shift
+		} else if x.signed {
+			x.u = uint64(x.int() >> shift)
+		} else {
+			x.u >>= shift
+		}
+		return x.norm(), nil
+	}
+
+	x, y = convert(x, y)
+	if x.float {
+		a, b := x.f, y.f
+		switch e.op {
+		case "+":
+			x.f = a + b
+		case "-":
+			x.f = a - b
+		case "*":
+			x.f = a * b
+		case "/":
+			x.f = a / b
+		case "==", "!=", "<", ">", "<=", ">=":
+			return boolNum(compare(e.op, a < b, a == b)), nil
+		default:
+			return num{}, fmt.Errorf("objc: %s of a floating value", e.op)
+		}
+		return x, nil
+	}
+	less := x.u < y.u
+	if x.signed {
+		less = x.int() < y.int()
+	}
+	switch e.op {
+	case "+":
+		x.u += y.u
+	case "-":
+		x.u -= y.u
+	case "*":
+		x.u *= y.u
+	case "/", "%":
+		if y.u == 0 {
+			return num{}, fmt.Errorf("objc: division by zero")
+		}
+		switch {
+		case x.signed && e.op == "/":
+			x.u = uint64(x.int() / y.int())
+		case x.signed:
+			x.u = uint64(x.int() % y.int())
+		case e.op == "/":
+			x.u /= y.u
+		default:
+			x.u %= y.u
+		}
+	case "&":
+		x.u &= y.u
+	case "|":
+		x.u |= y.u
+	case "^":
+		x.u ^= y.u
+	case "==", "!=", "<", ">", "<=", ">=":
+		return boolNum(compare(e.op, less, x.u == y.u)), nil
+	}
+	return x.norm(), nil
+}
+
+func compare(op string, less, equal bool) bool {
+	switch op {
+	case "==":
+		return equal
+	case "!=":
+		return !equal
+	case "<":
+		return less
+	case ">":
+		return !less && !equal
+	case "<=":
+		return less || equal
+	}
+	return !less
+}
@@ -0,0 +1,101 @@
+package objc
+
+import "testing"
+
+func TestEval(t *testing.T) {
+	consts := map[string]Value{
+		"kFoo": {Number: "1", Signed: true, Width: 32},
+		"kBar": {Number: "4", Signed: true, Width: 32},
+		"kTen": {Number: "10", Signed: true, Width: 32},
+	}
+	scope := Scope{
+		Const: func(name string) (Value, bool) {
+			v, ok := consts[name]
+			return v, ok
+		},
+		Type: func(t Type) (int, bool, bool, bool) {
+			switch t.Base {
+			case "NSUInteger":
+				return 64, false, false, true
+			case "CGFloat":
+				return 64, true, true, true
+			}
+			return 0, false, false, false
+		},
+	}
+	tests := []struct {
+		expr string
+		want Value
+	}{
+		{"42", Value{Number: "42", Signed: true, Width: 32}},
+		{"1UL << 4", Value{Number: "16", Width: 64}},
+		{"1 << 31", Value{Number: "-2147483648", Signed: true, Width: 32}},
+		{"kFoo | kBar", Value{Number: "5", Signed: true, Width: 32}},
+		// a parenthesized constant, not a cast, so * binds tighter
+		{"(kTen) - 1 * 2", Value{Number: "8", Signed: true, Width: 32}},
+		{"(kTen) + 1", Value{Number: "11", Signed: true, Width: 32}},
+		{"(NSUInteger)+1", Value{Number: "1", Width: 64}},
+		{"(int)-1 * 2", Value{Number: "-2", Signed: true, Width: 32}},
+		{"'abcd'", Value{Number: "1633837924", Signed: true, Width: 32}},
+		{"(NSUInteger)-1", Value{Number: "18446744073709551615", Width: 64}},
+		{"(CGFloat)1", Value{Number: "1", Signed: true, Width: 64, Float: true}},
+		{"0x10 >> 2", Value{Number: "4", Signed: true, Width: 32}},
+		{"3 >= 2", Value{Number: "1", Signed: true, Width: 32}},
+		{"-1 ? 2 : 3", Value{Number: "2", Signed: true, Width: 32}},
+		{"NSIntegerMax", Value{Number: "9223372036854775807", Signed: true, Width: 64}},
+	}
+	for _, test := range tests {
+		got, err := Eval(test.expr, scope)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", test.expr, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Eval(%q) = %+v, want %+v", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, expr := range []string{"kMissing", "1 +", "@\"string\""} {
+		if v, err := Eval(expr, Scope{}); err == nil {
+			t.Errorf("Eval(%q) = %+v, want an error", expr, v)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		v             Value
+		width         int
+		signed, float bool
+		want          Value
+	}{
+		// an int initializing a CGFloat
+		{Value{Number: "2", Signed: true, Width: 32}, 64, true, true, Value{Number: "2", Signed: true, Width: 64, Float: true}},
+		// -1 initializing an unsigned char
+		{Value{Number: "-1", Signed: true, Width: 32}, 8, false, false, Value{Number: "255", Width: 8}},
+		// a double initializing an int truncates
+		{Value{Number: "2.75", Signed: true, Width: 64, Float: true}, 32, true, false, Value{Number: "2", Signed: true, Width: 32}},
+	}
+	for _, test := range tests {
+		if got := test.v.Convert(test.width, test.signed, test.float); got != test.want {
+			t.Errorf("%+v.Convert(%d, %t, %t) = %+v, want %+v", test.v, test.width, test.signed, test.float, got, test.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		v, want Value
+	}{
+		{Value{Number: "0", Signed: true, Width: 32}, Value{Number: "1", Signed: true, Width: 32}},
+		{Value{Number: "-1", Signed: true, Width: 32}, Value{Number: "0", Signed: true, Width: 32}},
+		{Value{Number: "254", Width: 8}, Value{Number: "255", Width: 8}},
+	}
+	for _, test := range tests {
+		if got := test.v.Next(); got != test.want {
+			t.Errorf("%+v.Next() = %+v, want %+v", test.v, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package objc
+
+import "strings"
+
+// FunctionSignature is a parsed C function prototype such as
+//
+//	CGRect CGRectMake(CGFloat x, CGFloat y, CGFloat width, CGFloat height);
+type FunctionSignature struct {
+	Name       string
+	Return     Type
+	Params     []Param  `json:",omitempty"`
+	Variadic   bool     `json:",omitempty"` // ends with , ...
+	Attributes []string `json:",omitempty"` // NS_FORMAT_FUNCTION(1,2), CF_RETURNS_RETAINED, ...
+
+	// ReturnOwnership is retained, not_retained or inner_pointer, as
+	// annotated or, for CoreFoundation types, by the Create Rule.
+	ReturnOwnership string `json:",omitempty"`
+	CreateRule      bool   `json:",omitempty"` // ReturnOwnership inferred from the name
+}
+
+// Param is a function parameter. Name is empty for unnamed parameters.
+// Consumed parameters are released by the function.
+type Param struct {
+	Name     string `json:",omitempty"`
+	Type     Type
+	Consumed bool `json:",omitempty"`
+}
+
+// storage classes and export macros that may precede a function
+var linkageWords = map[string]bool{
+	"extern": true, "static": true, "inline": true, "__inline": true, "__inline__": true,
+}
+
+func isLinkage(word string) bool {
+	return linkageWords[word] ||
+		strings.HasSuffix(word, "_EXPORT") || strings.HasSuffix(word, "_EXTERN") ||
+		strings.HasSuffix(word, "_INLINE") || strings.HasSuffix(word, "_EXTERN_C_BEGIN")
+}
+
+// ParseFunction parses a C function prototype.
+func ParseFunction(decl string) (*FunctionSignature, error) {
+	p, err := newParser(decl)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if t := p.peek(); t.kind == tokIdent && isLinkage(t.text) {
+			p.next()
+			continue
+		}
+		if p.peek().is("__attribute__") || p.peek().is("__attribute") {
+			if err := p.skipAttributes(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		break
+	}
+
+	f := &FunctionSignature{}
+	start := p.pos
+	spec, err := p.parseSpecifiers()
+	if err != nil {
+		return nil, err
+	}
+	if f.Name, f.Return, err = p.parseDeclarator(spec, start, p.pos); err != nil {
+		return nil, err
+	}
+	if f.Name == "" {
+		return nil, p.errorf("expected function name")
+	}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if f.Params, f.Variadic, err = p.parseParams(); err != nil {
+		return nil, err
+	}
+
+	if f.Attributes, err = p.parseAttributes(); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected token")
+	}
+	f.ReturnOwnership = returnOwnership(f.Attributes, f.Return.Qualifiers)
+	if f.ReturnOwnership == "" && createRule(f.Name, f.Return) {
+		f.ReturnOwnership, f.CreateRule = "retained", true
+	}
+	return f, nil
+}
+
+// MatchParams compares the parameters of f with the names documented
+// for them. It returns the declared parameters that are not documented,
+// if any are, and the documented ones that are not declared. A
+// documented ... stands for the variable arguments of a variadic
+// function.
+func (f *FunctionSignature) MatchParams(documented []string) (undocumented, undeclared []string) {
+	declared := make(map[string]bool)
+	for _, p := range f.Params {
+		declared[p.Name] = true
+	}
+	docs := make(map[string]bool)
+	for _, name := range documented {
+		docs[name] = true
+	}
+	for _, p := range f.Params {
+		if documented != nil && p.Name != "" && !docs[p.Name] {
+			undocumented = append(undocumented, p.Name)
+		}
+	}
+	for _, name := range documented {
+		if !declared[name] && (name != "..." || !f.Variadic) {
+			undeclared = append(undeclared, name)
+		}
+	}
+	return undocumented, undeclared
+}
+
+// parseParams parses a parameter list up to and including its closing
+// parenthesis. A (void) list has no parameters.
+func (p *parser) parseParams() ([]Param, bool, error) {
+	if p.peek().is("void") && p.peekN(1).is(")") {
+		p.pos += 2
+		return nil, false, nil
+	}
+	var params []Param
+	variadic := false
+	for !p.accept(")") {
+		if params != nil || variadic {
+			if err := p.expect(","); err != nil {
+				return nil, false, err
+			}
+		}
+		if p.accept("...") {
+			variadic = true
+			continue
+		}
+		specStart := p.pos
+		spec, err := p.parseSpecifiers()
+		if err != nil {
+			return nil, false, err
+		}
+		specEnd := p.pos
+		name, t, err := p.parseDeclarator(spec, specStart, specEnd)
+		if err != nil {
+			return nil, false, err
+		}
+		attrs, err := p.parseAttributes()
+		if err != nil {
+			return nil, false, err
+		}
+		params = append(params, Param{Name: name, Type: t, Consumed: annotated(append(attrs, t.Qualifiers...), consumedAnnotations)})
+	}
+	return params, variadic, nil
+}
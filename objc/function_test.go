@@ -0,0 +1,118 @@
+package objc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFunction(t *testing.T) {
+	cgfloat := Type{Spelling: "CGFloat", Base: "CGFloat"}
+	voidPtr := Type{Spelling: "const void *", Base: "void", Pointers: 1, Qualifiers: []string{"const"}}
+	tests := []struct {
+		decl string
+		want *FunctionSignature
+	}{
+		{
+			"CGRect CGRectMake(CGFloat x, CGFloat y, CGFloat width, CGFloat height);",
+			&FunctionSignature{
+				Name:   "CGRectMake",
+				Return: Type{Spelling: "CGRect", Base: "CGRect"},
+				Params: []Param{
+					{Name: "x", Type: cgfloat}, {Name: "y", Type: cgfloat},
+					{Name: "width", Type: cgfloat}, {Name: "height", Type: cgfloat},
+				},
+			},
+		},
+		{
+			"FOUNDATION_EXPORT void NSLog(NSString *format, ...) NS_FORMAT_FUNCTION(1,2) NS_NO_TAIL_CALL;",
+			&FunctionSignature{
+				Name:       "NSLog",
+				Return:     Type{Spelling: "void", Base: "void"},
+				Params:     []Param{{Name: "format", Type: Type{Spelling: "NSString *", Base: "NSString", Pointers: 1}}},
+				Variadic:   true,
+				Attributes: []string{"NS_FORMAT_FUNCTION(1,2)", "NS_NO_TAIL_CALL"},
+			},
+		},
+		{
+			"extern double CACurrentMediaTime(void);",
+			&FunctionSignature{Name: "CACurrentMediaTime", Return: Type{Spelling: "double", Base: "double"}},
+		},
+		{
+			// unnamed parameters and a function pointer parameter
+			"void qsort(void *, size_t, size_t, int (*compar)(const void *, const void *));",
+			&FunctionSignature{
+				Name:   "qsort",
+				Return: Type{Spelling: "void", Base: "void"},
+				Params: []Param{
+					{Type: Type{Spelling: "void *", Base: "void", Pointers: 1}},
+					{Type: Type{Spelling: "size_t", Base: "size_t"}},
+					{Type: Type{Spelling: "size_t", Base: "size_t"}},
+					{Name: "compar", Type: Type{
+						Spelling: "int (*)(const void *, const void *)",
+						Func: &FuncType{
+							Return: Type{Spelling: "int", Base: "int"},
+							Params: []Param{{Type: voidPtr}, {Type: voidPtr}},
+						},
+					}},
+				},
+			},
+		},
+		{
+			"const char * _Nullable NSGetSizeAndAlignment(const char *typePtr, NSUInteger * _Nullable sizep, NSUInteger * _Nullable alignp);",
+			&FunctionSignature{
+				Name:   "NSGetSizeAndAlignment",
+				Return: Type{Spelling: "const char * _Nullable", Base: "char", Pointers: 1, Qualifiers: []string{"const", "_Nullable"}, Nullability: "nullable"},
+				Params: []Param{
+					{Name: "typePtr", Type: Type{Spelling: "const char *", Base: "char", Pointers: 1, Qualifiers: []string{"const"}}},
+					{Name: "sizep", Type: Type{Spelling: "NSUInteger * _Nullable", Base: "NSUInteger", Pointers: 1, Qualifiers: []string{"_Nullable"}, Nullability: "nullable"}},
+					{Name: "alignp", Type: Type{Spelling: "NSUInteger * _Nullable", Base: "NSUInteger", Pointers: 1, Qualifiers: []string{"_Nullable"}, Nullability: "nullable"}},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseFunction(test.decl)
+		if err != nil {
+			t.Errorf("ParseFunction(%q): %v", test.decl, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseFunction(%q) =\n%+v\nwant\n%+v", test.decl, got, test.want)
+		}
+	}
+}
+
+func TestParseFunctionErrors(t *testing.T) {
+	for _, decl := range []string{"void (int x);", "void f(int x", "int x;"} {
+		if f, err := ParseFunction(decl); err == nil {
+			t.Errorf("ParseFunction(%q) = %+v, want an error", decl, f)
+		}
+	}
+}
+
+func TestMatchParams(t *testing.T) {
+	tests := []struct {
+		decl                     string
+		documented               []string
+		undocumented, undeclared []string
+	}{
+		{"CGRect CGRectMake(CGFloat x, CGFloat y, CGFloat width, CGFloat height);", []string{"x", "y", "width", "height"}, nil, nil},
+		// nothing documented isn't a mismatch
+		{"CGRect CGRectMake(CGFloat x, CGFloat y, CGFloat width, CGFloat height);", nil, nil, nil},
+		{"CGRect CGRectMake(CGFloat x, CGFloat y, CGFloat width, CGFloat height);", []string{"x", "y", "w", "h"}, []string{"width", "height"}, []string{"w", "h"}},
+		{"void NSLog(NSString *format, ...);", []string{"format", "..."}, nil, nil},
+		{"void NSLogv(NSString *format, va_list args);", []string{"format", "..."}, []string{"args"}, []string{"..."}},
+		// unnamed parameters can't be documented
+		{"void qsort(void *, size_t, size_t, int (*compar)(const void *, const void *));", []string{"compar"}, nil, nil},
+	}
+	for _, test := range tests {
+		f, err := ParseFunction(test.decl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		undocumented, undeclared := f.MatchParams(test.documented)
+		if !reflect.DeepEqual(undocumented, test.undocumented) || !reflect.DeepEqual(undeclared, test.undeclared) {
+			t.Errorf("MatchParams(%q) of %s = %q, %q, want %q, %q", test.documented, test.decl, undocumented, undeclared, test.undocumented, test.undeclared)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package objc
+
+// Interface is a parsed class or protocol declaration such as
+//
+//	@interface NSMutableArray<ObjectType> : NSArray<ObjectType>
+//	@protocol NSWindowDelegate <NSObject>
+type Interface struct {
+	Protocol   bool `json:",omitempty"` // @protocol rather than @interface
+	Name       string
+	TypeParams []TypeParam `json:",omitempty"`
+	Superclass *Type       `json:",omitempty"` // with the type arguments passed to it
+	Protocols  []string    `json:",omitempty"` // adopted protocols
+	Attributes []string    `json:",omitempty"`
+}
+
+// TypeParam is a type parameter of a generic class. Variance is
+// covariant or contravariant, if declared. Bound is the type arguments
+// must be a kind of, if declared.
+type TypeParam struct {
+	Name     string
+	Variance string `json:",omitempty"`
+	Bound    *Type  `json:",omitempty"`
+}
+
+// ParseInterface parses a class or protocol declaration.
+func ParseInterface(decl string) (*Interface, error) {
+	p, err := newParser(decl)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("@"); err != nil {
+		return nil, err
+	}
+	it := &Interface{}
+	switch {
+	case p.accept("protocol"):
+		it.Protocol = true
+	case p.accept("interface"):
+	default:
+		return nil, p.errorf("expected @interface or @protocol")
+	}
+	if p.peek().kind != tokIdent {
+		return nil, p.errorf("expected name")
+	}
+	it.Name = p.next().text
+
+	// a list after the name has the type parameters of a class with a
+	// superclass, @interface NSArray<ObjectType> : NSObject, or else the
+	// protocols of a root class or protocol, @interface NSObject <NSObject>
+	if !it.Protocol && p.peek().is("<") && p.listFollowedBy(":") {
+		p.next()
+		for {
+			var tp TypeParam
+			switch {
+			case p.accept("__covariant"):
+				tp.Variance = "covariant"
+			case p.accept("__contravariant"):
+				tp.Variance = "contravariant"
+			}
+			if p.peek().kind != tokIdent {
+				return nil, p.errorf("expected type parameter")
+			}
+			tp.Name = p.next().text
+			if p.accept(":") {
+				start := p.pos
+				bound, err := p.parseType()
+				if err != nil {
+					return nil, err
+				}
+				bound.Spelling = p.spelling(start, p.pos)
+				tp.Bound = &bound
+			}
+			it.TypeParams = append(it.TypeParams, tp)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+	}
+
+	if !it.Protocol && p.accept(":") {
+		if p.peek().kind != tokIdent {
+			return nil, p.errorf("expected superclass")
+		}
+		start := p.pos
+		super := Type{Base: p.next().text}
+		// the list after the superclass has its type arguments if the
+		// protocols follow in a second list, or if it can't be protocols:
+		// : NSArray<ObjectType>, : NSObject <NSCopying>
+		if p.peek().is("<") && (p.listFollowedBy("<") || !p.protocolList(it.TypeParams)) {
+			p.next()
+			if err := p.parseTypeArgs(&super); err != nil {
+				return nil, err
+			}
+		}
+		super.Spelling = p.spelling(start, p.pos)
+		it.Superclass = &super
+	}
+
+	if p.accept("<") {
+		for {
+			if p.peek().kind != tokIdent {
+				return nil, p.errorf("expected protocol")
+			}
+			it.Protocols = append(it.Protocols, p.next().text)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+	}
+
+	if it.Attributes, err = p.parseAttributes(); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected token")
+	}
+	return it, nil
+}
+
+// listFollowedBy reports whether the angle bracketed list at the current
+// position is followed by text.
+func (p *parser) listFollowedBy(text string) bool {
+	end, ok := p.listEnd()
+	return ok && end+1 < len(p.toks) && p.toks[end+1].is(text)
+}
+
+// protocolList reports whether the angle bracketed list at the current
+// position names only protocols: names that are not one of params.
+func (p *parser) protocolList(params []TypeParam) bool {
+	end, ok := p.listEnd()
+	if !ok {
+		return false
+	}
+	for i := p.pos + 1; i < end; i += 2 {
+		tok := p.toks[i]
+		if tok.kind != tokIdent || (i+1 < end && !p.toks[i+1].is(",")) {
+			return false
+		}
+		for _, tp := range params {
+			if tok.text == tp.Name {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// listEnd returns the index of the bracket closing the angle bracketed
+// list at the current position.
+func (p *parser) listEnd() (int, bool) {
+	sub := &parser{src: p.src, toks: p.toks, pos: p.pos + 1}
+	end, err := sub.matching(">")
+	return end, err == nil
+}
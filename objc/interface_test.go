@@ -0,0 +1,78 @@
+package objc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInterface(t *testing.T) {
+	objectType := []TypeParam{{Name: "ObjectType"}}
+	nsarray := &Type{
+		Spelling: "NSArray<ObjectType>",
+		Base:     "NSArray",
+		Args:     []Type{{Spelling: "ObjectType", Base: "ObjectType"}},
+	}
+	tests := []struct {
+		decl string
+		want *Interface
+	}{
+		{
+			"@interface NSWindow : NSResponder",
+			&Interface{Name: "NSWindow", Superclass: &Type{Spelling: "NSResponder", Base: "NSResponder"}},
+		},
+		{
+			"@interface NSArray<__covariant ObjectType> : NSObject <NSCopying, NSMutableCopying>",
+			&Interface{
+				Name:       "NSArray",
+				TypeParams: []TypeParam{{Name: "ObjectType", Variance: "covariant"}},
+				Superclass: &Type{Spelling: "NSObject", Base: "NSObject"},
+				Protocols:  []string{"NSCopying", "NSMutableCopying"},
+			},
+		},
+		{
+			// positions decide, not spacing
+			"@interface NSArray <ObjectType>:NSObject<NSCopying>",
+			&Interface{
+				Name:       "NSArray",
+				TypeParams: objectType,
+				Superclass: &Type{Spelling: "NSObject", Base: "NSObject"},
+				Protocols:  []string{"NSCopying"},
+			},
+		},
+		{
+			"@interface NSMutableArray<ObjectType> : NSArray<ObjectType>",
+			&Interface{Name: "NSMutableArray", TypeParams: objectType, Superclass: nsarray},
+		},
+		{
+			"@interface NSMutableArray<ObjectType> : NSArray<ObjectType> <NSSecureCoding>",
+			&Interface{Name: "NSMutableArray", TypeParams: objectType, Superclass: nsarray, Protocols: []string{"NSSecureCoding"}},
+		},
+		{
+			"@interface NSObject<NSObject>",
+			&Interface{Name: "NSObject", Protocols: []string{"NSObject"}},
+		},
+		{
+			"@protocol NSWindowDelegate<NSObject>",
+			&Interface{Protocol: true, Name: "NSWindowDelegate", Protocols: []string{"NSObject"}},
+		},
+		{
+			"@interface NSValueTransformer<T : NSString *> : NSObject API_AVAILABLE(macos(10.3));",
+			&Interface{
+				Name:       "NSValueTransformer",
+				TypeParams: []TypeParam{{Name: "T", Bound: &Type{Spelling: "NSString *", Base: "NSString", Pointers: 1}}},
+				Superclass: &Type{Spelling: "NSObject", Base: "NSObject"},
+				Attributes: []string{"API_AVAILABLE(macos(10.3))"},
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseInterface(test.decl)
+		if err != nil {
+			t.Errorf("ParseInterface(%q): %v", test.decl, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseInterface(%q) =\n%+v\nwant\n%+v", test.decl, got, test.want)
+		}
+	}
+}
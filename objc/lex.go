@@ -0,0 +1,219 @@
+// Package objc parses the C and Objective-C declarations found in the
+// symbols database into structured signatures and types.
+package objc
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokChar
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	start int // byte offsets into the source
+	end   int
+}
+
+func (t token) is(text string) bool {
+	return t.kind != tokEOF && t.text == text
+}
+
+// three and two character punctuators, longest first. Those starting
+// with > are left as single characters, so the >> closing nested type
+// arguments lexes as two brackets. Expressions rejoin them.
+var punctuators = []string{
+	"...", "<<=",
+	"<<", "<=", "==", "!=", "&&", "||", "->", "++", "--",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "::",
+}
+
+// lex splits a declaration into tokens, dropping whitespace and comments.
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at %d", i)
+			}
+			i += 2 + end + 2
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentChar(src[i]) {
+				i++
+			}
+			toks = append(toks, token{tokIdent, src[start:i], start, i})
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isIdentChar(src[i]) || src[i] == '.' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E' || src[i-1] == 'p' || src[i-1] == 'P') && !isHex(src[start:i]))) {
+				i++
+			}
+			toks = append(toks, token{tokNumber, src[start:i], start, i})
+		case c == '\'' || c == '"' || (c == '@' && i+1 < len(src) && src[i+1] == '"'):
+			start := i
+			if c == '@' {
+				i++
+			}
+			quote := src[i]
+			i++
+			for i < len(src) && src[i] != quote {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated literal at %d", start)
+			}
+			i++
+			kind := tokString
+			if quote == '\'' {
+				kind = tokChar
+			}
+			toks = append(toks, token{kind, src[start:i], start, i})
+		default:
+			start := i
+			n := 1
+			for _, p := range punctuators {
+				if strings.HasPrefix(src[i:], p) {
+					n = len(p)
+					break
+				}
+			}
+			i += n
+			toks = append(toks, token{tokPunct, src[start:i], start, i})
+		}
+	}
+	return toks, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(s string) bool {
+	return strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")
+}
+
+// parser is a cursor over the tokens of one declaration.
+type parser struct {
+	src  string
+	toks []token
+	pos  int
+
+	// scope of a constant expression being evaluated, which tells casts
+	// from parenthesized names
+	scope Scope
+}
+
+func newParser(src string) (*parser, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{src: src, toks: toks}, nil
+}
+
+func (p *parser) peek() token {
+	return p.peekN(0)
+}
+
+func (p *parser) peekN(n int) token {
+	if p.pos+n < len(p.toks) {
+		return p.toks[p.pos+n]
+	}
+	return token{kind: tokEOF, start: len(p.src), end: len(p.src)}
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if p.pos < len(p.toks) {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(text string) bool {
+	if p.peek().is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %q", text)
+	}
+	return nil
+}
+
+func (p *parser) atEnd() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	t := p.peek()
+	found := t.text
+	if t.kind == tokEOF {
+		found = "end of declaration"
+	}
+	return fmt.Errorf("objc: %s, found %q at %d", fmt.Sprintf(format, args...), found, t.start)
+}
+
+// skipBalanced skips an opening bracket and everything up to its match.
+func (p *parser) skipBalanced() error {
+	open := p.next().text
+	close := map[string]string{"(": ")", "[": "]", "{": "}", "<": ">"}[open]
+	depth := 1
+	for depth > 0 {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			return fmt.Errorf("objc: unbalanced %q", open)
+		case t.text == open:
+			depth++
+		case t.text == close:
+			depth--
+		}
+	}
+	return nil
+}
+
+// spelling returns the source between two token positions with runs of
+// whitespace collapsed.
+func (p *parser) spelling(from, to int) string {
+	if from >= to || from >= len(p.toks) {
+		return ""
+	}
+	return strings.Join(strings.Fields(p.src[p.toks[from].start:p.toks[to-1].end]), " ")
+}
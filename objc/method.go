@@ -0,0 +1,143 @@
+package objc
+
+import "strings"
+
+// MethodSignature is a parsed Objective-C method declaration such as
+//
+//   - (BOOL)application:(NSApplication *)sender openFile:(NSString *)filename;
+type MethodSignature struct {
+	Class      bool     // declared with + rather than -
+	Selector   string   // application:openFile:
+	Parts      []string // application, openFile
+	Args       []Arg    `json:",omitempty"`
+	Return     Type
+	Variadic   bool     `json:",omitempty"` // ends with , ...
+	Attributes []string `json:",omitempty"` // NS_DESIGNATED_INITIALIZER, API_AVAILABLE(macos(10.10)), ...
+
+	ReturnOwnership       string `json:",omitempty"` // retained, not_retained or inner_pointer, if annotated
+	ConsumesSelf          bool   `json:",omitempty"` // NS_CONSUMES_SELF
+	DesignatedInitializer bool   `json:",omitempty"` // NS_DESIGNATED_INITIALIZER
+}
+
+// Arg is a method argument. Label is the selector part it follows.
+// Consumed arguments are released by the method.
+type Arg struct {
+	Label    string
+	Name     string
+	Type     Type
+	Consumed bool `json:",omitempty"`
+}
+
+// ParseMethod parses an Objective-C method declaration.
+func ParseMethod(decl string) (*MethodSignature, error) {
+	p, err := newParser(decl)
+	if err != nil {
+		return nil, err
+	}
+	m := &MethodSignature{}
+	switch {
+	case p.accept("+"):
+		m.Class = true
+	case p.accept("-"):
+	default:
+		return nil, p.errorf("expected - or +")
+	}
+
+	// an omitted return type is id
+	m.Return = Type{Spelling: "id", Base: "id"}
+	if p.accept("(") {
+		if m.Return, err = p.parseMethodType(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek().kind != tokIdent {
+		return nil, p.errorf("expected selector")
+	}
+	if !p.peekN(1).is(":") {
+		// a unary selector takes no arguments
+		first := p.next()
+		m.Parts = []string{first.text}
+		m.Selector = first.text
+	}
+	for m.Selector == "" || m.Args != nil {
+		part := ""
+		if p.peek().kind == tokIdent && p.peekN(1).is(":") {
+			part = p.next().text
+		}
+		if !p.accept(":") {
+			break
+		}
+		arg := Arg{Label: part, Type: Type{Spelling: "id", Base: "id"}}
+		if p.accept("(") {
+			if arg.Type, err = p.parseMethodType(); err != nil {
+				return nil, err
+			}
+		}
+		// attributes may follow the type: (id) NS_CONSUMED obj
+		attrs := arg.Type.Qualifiers
+		for t := p.peek(); t.kind == tokIdent && (qualifierWords[t.text] || t.is("__attribute__")); t = p.peek() {
+			attr, err := p.parseAttribute()
+			if err != nil {
+				return nil, err
+			}
+			attrs = append(attrs, attr)
+		}
+		arg.Consumed = annotated(attrs, consumedAnnotations)
+		if p.peek().kind != tokIdent {
+			return nil, p.errorf("expected argument name")
+		}
+		arg.Name = p.next().text
+		m.Parts = append(m.Parts, part)
+		m.Selector += part + ":"
+		m.Args = append(m.Args, arg)
+	}
+	if m.Parts == nil {
+		return nil, p.errorf("expected selector")
+	}
+
+	if p.accept(",") {
+		if err := p.expect("..."); err != nil {
+			return nil, err
+		}
+		m.Variadic = true
+	}
+
+	if m.Attributes, err = p.parseAttributes(); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected token")
+	}
+	m.ReturnOwnership = returnOwnership(m.Attributes, m.Return.Qualifiers)
+	m.ConsumesSelf = annotated(m.Attributes, map[string]bool{"NS_CONSUMES_SELF": true, "ns_consumes_self": true})
+	m.DesignatedInitializer = annotated(m.Attributes, map[string]bool{"NS_DESIGNATED_INITIALIZER": true, "objc_designated_initializer": true})
+	return m, nil
+}
+
+// parseAttributes parses trailing attribute macros and __attribute__
+// lists up to the end of a declaration.
+func (p *parser) parseAttributes() ([]string, error) {
+	var attrs []string
+	for p.peek().kind == tokIdent {
+		attr, err := p.parseAttribute()
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs, nil
+}
+
+// parseAttribute parses one attribute macro or __attribute__ list.
+func (p *parser) parseAttribute() (string, error) {
+	start := p.pos
+	p.next()
+	if p.peek().is("(") {
+		if err := p.skipBalanced(); err != nil {
+			return "", err
+		}
+	}
+	return strings.ReplaceAll(p.spelling(start, p.pos), " (", "("), nil
+}
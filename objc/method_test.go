@@ -0,0 +1,158 @@
+package objc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMethod(t *testing.T) {
+	tests := []struct {
+		decl string
+		want *MethodSignature
+	}{
+		{
+			"- (BOOL)application:(NSApplication *)sender openFile:(NSString *)filename;",
+			&MethodSignature{
+				Selector: "application:openFile:",
+				Parts:    []string{"application", "openFile"},
+				Args: []Arg{
+					{Label: "application", Name: "sender", Type: Type{Spelling: "NSApplication *", Base: "NSApplication", Pointers: 1}},
+					{Label: "openFile", Name: "filename", Type: Type{Spelling: "NSString *", Base: "NSString", Pointers: 1}},
+				},
+				Return: Type{Spelling: "BOOL", Base: "BOOL"},
+			},
+		},
+		{
+			"+ (instancetype)new",
+			&MethodSignature{
+				Class:    true,
+				Selector: "new",
+				Parts:    []string{"new"},
+				Return:   Type{Spelling: "instancetype", Base: "instancetype"},
+			},
+		},
+		{
+			"- init NS_DESIGNATED_INITIALIZER;",
+			&MethodSignature{
+				Selector:              "init",
+				Parts:                 []string{"init"},
+				Return:                Type{Spelling: "id", Base: "id"},
+				Attributes:            []string{"NS_DESIGNATED_INITIALIZER"},
+				DesignatedInitializer: true,
+			},
+		},
+		{
+			// Objective-C type qualifiers lead the parenthesized types
+			"- (oneway void)release:(in id)obj error:(out NSError **)error;",
+			&MethodSignature{
+				Selector: "release:error:",
+				Parts:    []string{"release", "error"},
+				Args: []Arg{
+					{Label: "release", Name: "obj", Type: Type{Spelling: "in id", Base: "id", Qualifiers: []string{"in"}}},
+					{Label: "error", Name: "error", Type: Type{Spelling: "out NSError **", Base: "NSError", Pointers: 2, Qualifiers: []string{"out"}}},
+				},
+				Return: Type{Spelling: "oneway void", Base: "void", Qualifiers: []string{"oneway"}},
+			},
+		},
+		{
+			// but are names elsewhere
+			"- (void)setIn:(id)in out:(id)out;",
+			&MethodSignature{
+				Selector: "setIn:out:",
+				Parts:    []string{"setIn", "out"},
+				Args: []Arg{
+					{Label: "setIn", Name: "in", Type: Type{Spelling: "id", Base: "id"}},
+					{Label: "out", Name: "out", Type: Type{Spelling: "id", Base: "id"}},
+				},
+				Return: Type{Spelling: "void", Base: "void"},
+			},
+		},
+		{
+			"- (void)beginSheet:(NSWindow *)sheetWindow completionHandler:(void (^)(NSModalResponse returnCode))handler;",
+			&MethodSignature{
+				Selector: "beginSheet:completionHandler:",
+				Parts:    []string{"beginSheet", "completionHandler"},
+				Args: []Arg{
+					{Label: "beginSheet", Name: "sheetWindow", Type: Type{Spelling: "NSWindow *", Base: "NSWindow", Pointers: 1}},
+					{Label: "completionHandler", Name: "handler", Type: Type{
+						Spelling: "void (^)(NSModalResponse returnCode)",
+						Func: &FuncType{
+							Return:   Type{Spelling: "void", Base: "void"},
+							Params:   []Param{{Name: "returnCode", Type: Type{Spelling: "NSModalResponse", Base: "NSModalResponse"}}},
+							Block:    true,
+							Escaping: true,
+						},
+					}},
+				},
+				Return: Type{Spelling: "void", Base: "void"},
+			},
+		},
+		{
+			// NS_NOESCAPE blocks don't escape
+			"- (void)enumerateObjectsUsingBlock:(void (NS_NOESCAPE ^)(id obj, NSUInteger idx, BOOL *stop))block;",
+			&MethodSignature{
+				Selector: "enumerateObjectsUsingBlock:",
+				Parts:    []string{"enumerateObjectsUsingBlock"},
+				Args: []Arg{
+					{Label: "enumerateObjectsUsingBlock", Name: "block", Type: Type{
+						Spelling: "void (NS_NOESCAPE ^)(id obj, NSUInteger idx, BOOL *stop)",
+						Func: &FuncType{
+							Return: Type{Spelling: "void", Base: "void"},
+							Params: []Param{
+								{Name: "obj", Type: Type{Spelling: "id", Base: "id"}},
+								{Name: "idx", Type: Type{Spelling: "NSUInteger", Base: "NSUInteger"}},
+								{Name: "stop", Type: Type{Spelling: "BOOL *", Base: "BOOL", Pointers: 1}},
+							},
+							Block: true,
+						},
+					}},
+				},
+				Return: Type{Spelling: "void", Base: "void"},
+			},
+		},
+		{
+			"- (NSArray<NSString *> *)componentsSeparatedByString:(NSString *)separator;",
+			&MethodSignature{
+				Selector: "componentsSeparatedByString:",
+				Parts:    []string{"componentsSeparatedByString"},
+				Args: []Arg{
+					{Label: "componentsSeparatedByString", Name: "separator", Type: Type{Spelling: "NSString *", Base: "NSString", Pointers: 1}},
+				},
+				Return: Type{
+					Spelling: "NSArray<NSString *> *",
+					Base:     "NSArray",
+					Pointers: 1,
+					Args:     []Type{{Spelling: "NSString *", Base: "NSString", Pointers: 1}},
+				},
+			},
+		},
+		{
+			"- (void)logFormat:(NSString *)format, ...;",
+			&MethodSignature{
+				Selector: "logFormat:",
+				Parts:    []string{"logFormat"},
+				Args:     []Arg{{Label: "logFormat", Name: "format", Type: Type{Spelling: "NSString *", Base: "NSString", Pointers: 1}}},
+				Return:   Type{Spelling: "void", Base: "void"},
+				Variadic: true,
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseMethod(test.decl)
+		if err != nil {
+			t.Errorf("ParseMethod(%q): %v", test.decl, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseMethod(%q) =\n%+v\nwant\n%+v", test.decl, got, test.want)
+		}
+	}
+}
+
+func TestParseMethodErrors(t *testing.T) {
+	for _, decl := range []string{"(void)foo", "- (void)", "- (void)foo:(id)"} {
+		if m, err := ParseMethod(decl); err == nil {
+			t.Errorf("ParseMethod(%q) = %+v, want an error", decl, m)
+		}
+	}
+}
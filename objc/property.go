@@ -0,0 +1,137 @@
+package objc
+
+import "strings"
+
+// PropertyInfo is a parsed Objective-C property declaration such as
+//
+//	@property(nonatomic, copy, readonly, nullable, getter=isEnabled, class) NSString *title;
+//
+// Nullability is taken from the attributes or from the type's
+// qualifiers, normalized to nullable, nonnull, null_unspecified or
+// null_resettable. Memory is the declared memory semantics (strong, weak,
+// copy, assign, retain, unsafe_unretained) and is empty when the default
+// applies.
+type PropertyInfo struct {
+	Name        string
+	Type        Type
+	Nullability string   `json:",omitempty"`
+	Memory      string   `json:",omitempty"`
+	Atomic      bool     // no nonatomic attribute
+	Readonly    bool     `json:",omitempty"`
+	Class       bool     `json:",omitempty"`
+	Getter      string   `json:",omitempty"`
+	Setter      string   `json:",omitempty"`
+	Attributes  []string `json:",omitempty"` // trailing macros: API_AVAILABLE(macos(10.12)), ...
+}
+
+var memoryAttributes = map[string]bool{
+	"strong": true, "weak": true, "copy": true, "assign": true, "retain": true, "unsafe_unretained": true,
+}
+
+// nullability spellings as attributes and as type qualifiers
+var nullabilities = map[string]string{
+	"nullable": "nullable", "_Nullable": "nullable", "__nullable": "nullable", "_Nullable_result": "nullable",
+	"nonnull": "nonnull", "_Nonnull": "nonnull", "__nonnull": "nonnull",
+	"null_unspecified": "null_unspecified", "_Null_unspecified": "null_unspecified", "__null_unspecified": "null_unspecified",
+	"null_resettable": "null_resettable",
+}
+
+// ParseProperty parses an Objective-C property declaration.
+func ParseProperty(decl string) (*PropertyInfo, error) {
+	p, err := newParser(decl)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("@"); err != nil {
+		return nil, err
+	}
+	if err := p.expect("property"); err != nil {
+		return nil, err
+	}
+	prop := &PropertyInfo{Atomic: true}
+	if p.accept("(") {
+		for !p.accept(")") {
+			if p.peek().kind != tokIdent {
+				return nil, p.errorf("expected property attribute")
+			}
+			attr := p.next().text
+			switch {
+			case attr == "nonatomic":
+				prop.Atomic = false
+			case attr == "atomic":
+				prop.Atomic = true
+			case attr == "readonly":
+				prop.Readonly = true
+			case attr == "readwrite":
+				prop.Readonly = false
+			case attr == "class":
+				prop.Class = true
+			case memoryAttributes[attr]:
+				prop.Memory = attr
+			case nullabilities[attr] != "":
+				prop.Nullability = nullabilities[attr]
+			case attr == "getter" || attr == "setter":
+				if err := p.expect("="); err != nil {
+					return nil, err
+				}
+				sel := ""
+				for !p.peek().is(",") && !p.peek().is(")") && !p.atEnd() {
+					sel += p.next().text
+				}
+				if attr == "getter" {
+					prop.Getter = sel
+				} else {
+					prop.Setter = sel
+				}
+			}
+			if !p.accept(",") && !p.peek().is(")") {
+				return nil, p.errorf("expected , or )")
+			}
+		}
+	}
+
+	start := p.pos
+	spec, err := p.parseSpecifiers()
+	if err != nil {
+		return nil, err
+	}
+	if prop.Name, prop.Type, err = p.parseDeclarator(spec, start, p.pos); err != nil {
+		return nil, err
+	}
+	if prop.Name == "" {
+		return nil, p.errorf("expected property name")
+	}
+	if prop.Nullability == "" {
+		prop.Nullability = prop.Type.Nullability
+	}
+
+	if prop.Attributes, err = p.parseAttributes(); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected token")
+	}
+	return prop, nil
+}
+
+// GetterSelector returns the getter selector, the property name unless
+// a custom getter is declared.
+func (prop *PropertyInfo) GetterSelector() string {
+	if prop.Getter != "" {
+		return prop.Getter
+	}
+	return prop.Name
+}
+
+// SetterSelector returns the setter selector, or "" for readonly
+// properties.
+func (prop *PropertyInfo) SetterSelector() string {
+	if prop.Setter != "" {
+		return prop.Setter
+	}
+	if prop.Readonly || prop.Name == "" {
+		return ""
+	}
+	return "set" + strings.ToUpper(prop.Name[:1]) + prop.Name[1:] + ":"
+}
@@ -0,0 +1,71 @@
+package objc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProperty(t *testing.T) {
+	tests := []struct {
+		decl           string
+		want           *PropertyInfo
+		getter, setter string
+	}{
+		{
+			"@property(nonatomic, copy, readonly, nullable, getter=isEnabled, class) NSString *title;",
+			&PropertyInfo{
+				Name:        "title",
+				Type:        Type{Spelling: "NSString *", Base: "NSString", Pointers: 1},
+				Nullability: "nullable",
+				Memory:      "copy",
+				Readonly:    true,
+				Class:       true,
+				Getter:      "isEnabled",
+			},
+			"isEnabled", "",
+		},
+		{
+			"@property (weak) id<NSWindowDelegate> _Nullable delegate API_AVAILABLE(macos(10.6));",
+			&PropertyInfo{
+				Name: "delegate",
+				Type: Type{
+					Spelling:    "id<NSWindowDelegate> _Nullable",
+					Base:        "id",
+					Qualifiers:  []string{"_Nullable"},
+					Nullability: "nullable",
+					Protocols:   []string{"NSWindowDelegate"},
+				},
+				Nullability: "nullable",
+				Memory:      "weak",
+				Atomic:      true,
+				Attributes:  []string{"API_AVAILABLE(macos(10.6))"},
+			},
+			"delegate", "setDelegate:",
+		},
+		{
+			"@property(nonatomic, setter=setOn:) BOOL on;",
+			&PropertyInfo{
+				Name:   "on",
+				Type:   Type{Spelling: "BOOL", Base: "BOOL"},
+				Setter: "setOn:",
+			},
+			"on", "setOn:",
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseProperty(test.decl)
+		if err != nil {
+			t.Errorf("ParseProperty(%q): %v", test.decl, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseProperty(%q) =\n%+v\nwant\n%+v", test.decl, got, test.want)
+		}
+		if sel := got.GetterSelector(); sel != test.getter {
+			t.Errorf("ParseProperty(%q).GetterSelector() = %q, want %q", test.decl, sel, test.getter)
+		}
+		if sel := got.SetterSelector(); sel != test.setter {
+			t.Errorf("ParseProperty(%q).SetterSelector() = %q, want %q", test.decl, sel, test.setter)
+		}
+	}
+}
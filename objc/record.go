@@ -0,0 +1,177 @@
+package objc
+
+import "strconv"
+
+// Record is a parsed C struct or union declaration such as
+//
+//	typedef struct CGPoint {
+//	    CGFloat x;
+//	    CGFloat y;
+//	} CGPoint;
+//
+// Fields is empty for opaque declarations.
+type Record struct {
+	Kind   string  // struct or union
+	Tag    string  `json:",omitempty"` // CGPoint of struct CGPoint
+	Name   string  `json:",omitempty"` // typedef name
+	Fields []Field `json:",omitempty"`
+}
+
+// Field is a member of a struct or union. A nested struct or union has
+// its Type.Base set to "struct" or "union" followed by its tag, if any,
+// and its members in Fields. Name is empty for anonymous members and
+// unnamed bitfields.
+type Field struct {
+	Name   string `json:",omitempty"`
+	Type   Type
+	Bits   int     `json:",omitempty"` // bitfield width
+	Fields []Field `json:",omitempty"`
+	Path   string  `json:",omitempty"` // documentation page of the field
+}
+
+// ParseRecord parses a struct or union declaration, typedef'd or not.
+func ParseRecord(decl string) (*Record, error) {
+	p, err := newParser(decl)
+	if err != nil {
+		return nil, err
+	}
+	typedef := p.accept("typedef")
+	if !p.peek().is("struct") && !p.peek().is("union") {
+		return nil, p.errorf("expected struct or union")
+	}
+	r := &Record{Kind: p.next().text}
+	if err := p.skipAttributes(); err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokIdent && !p.peekN(1).is("(") {
+		r.Tag = p.next().text
+	}
+	if p.accept("{") {
+		if r.Fields, err = p.parseFields(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.skipAttributes(); err != nil {
+		return nil, err
+	}
+	if typedef {
+		// the first declarator that is not a pointer names the record
+		for {
+			pointer := false
+			for p.accept("*") {
+				pointer = true
+			}
+			if p.peek().kind != tokIdent {
+				break
+			}
+			name := p.next().text
+			if r.Name == "" && !pointer {
+				r.Name = name
+			}
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if _, err := p.parseAttributes(); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected token")
+	}
+	return r, nil
+}
+
+// parseFields parses member declarations up to and including the closing
+// brace of a struct or union body.
+func (p *parser) parseFields() ([]Field, error) {
+	fields := []Field{}
+	for !p.accept("}") {
+		if p.atEnd() {
+			return nil, p.errorf("expected }")
+		}
+		specStart, tagEnd := p.pos, -1
+		var spec Type
+		var nested []Field
+		if (p.peek().is("struct") || p.peek().is("union")) &&
+			(p.peekN(1).is("{") || (p.peekN(1).kind == tokIdent && p.peekN(2).is("{"))) {
+			spec.Base = p.next().text
+			if p.peek().kind == tokIdent {
+				spec.Base += " " + p.next().text
+			}
+			// spell nested members by their tag, not their body
+			tagEnd = p.pos
+			p.next()
+			var err error
+			if nested, err = p.parseFields(); err != nil {
+				return nil, err
+			}
+		} else {
+			var err error
+			if spec, err = p.parseSpecifiers(); err != nil {
+				return nil, err
+			}
+		}
+		specEnd := p.pos
+		if tagEnd >= 0 {
+			specEnd = tagEnd
+		}
+
+		if p.accept(";") {
+			// anonymous struct or union member
+			spec.Spelling = p.spelling(specStart, specEnd)
+			fields = append(fields, Field{Type: spec, Fields: nested})
+			continue
+		}
+		for {
+			name, t, err := p.parseDeclarator(spec, specStart, specEnd)
+			if err != nil {
+				return nil, err
+			}
+			f := Field{Name: name, Type: t, Fields: nested}
+			if p.accept(":") {
+				bits := p.next()
+				if f.Bits, err = strconv.Atoi(bits.text); err != nil {
+					return nil, p.errorf("expected bitfield width")
+				}
+			}
+			if _, err := p.parseAttributes(); err != nil {
+				return nil, err
+			}
+			fields = append(fields, f)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+// LinkFields sets the Path of the named fields, those of nested structs
+// and unions included, to the documentation page path returns for their
+// name, if any.
+func LinkFields(fields []Field, path func(name string) string) {
+	for i := range fields {
+		LinkFields(fields[i].Fields, path)
+		if fields[i].Name != "" {
+			fields[i].Path = path(fields[i].Name)
+		}
+	}
+}
+
+// skipAttributes skips __attribute__ lists.
+func (p *parser) skipAttributes() error {
+	for p.peek().is("__attribute__") || p.peek().is("__attribute") {
+		p.next()
+		if p.peek().is("(") {
+			if err := p.skipBalanced(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package objc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRecord(t *testing.T) {
+	cgfloat := Type{Spelling: "CGFloat", Base: "CGFloat"}
+	unsigned := Type{Spelling: "unsigned int", Base: "unsigned int"}
+	tests := []struct {
+		decl string
+		want *Record
+	}{
+		{
+			"typedef struct CGPoint { CGFloat x; CGFloat y; } CGPoint;",
+			&Record{Kind: "struct", Tag: "CGPoint", Name: "CGPoint", Fields: []Field{
+				{Name: "x", Type: cgfloat},
+				{Name: "y", Type: cgfloat},
+			}},
+		},
+		{
+			"struct CGPoint;",
+			&Record{Kind: "struct", Tag: "CGPoint"},
+		},
+		{
+			// pointer typedefs don't name the record
+			"typedef struct __CFString *CFMutableStringRef;",
+			&Record{Kind: "struct", Tag: "__CFString"},
+		},
+		{
+			"typedef struct _NSRange { NSUInteger location; NSUInteger length; } NSRange, *NSRangePointer;",
+			&Record{Kind: "struct", Tag: "_NSRange", Name: "NSRange", Fields: []Field{
+				{Name: "location", Type: Type{Spelling: "NSUInteger", Base: "NSUInteger"}},
+				{Name: "length", Type: Type{Spelling: "NSUInteger", Base: "NSUInteger"}},
+			}},
+		},
+		{
+			// bitfields, unnamed ones included
+			"typedef struct { unsigned int isDirectory : 1, isHidden : 1; unsigned int : 30; } Flags;",
+			&Record{Kind: "struct", Name: "Flags", Fields: []Field{
+				{Name: "isDirectory", Type: unsigned, Bits: 1},
+				{Name: "isHidden", Type: unsigned, Bits: 1},
+				{Type: unsigned, Bits: 30},
+			}},
+		},
+		{
+			// arrays, of pointers and of several dimensions
+			"struct Buffers { char name[16]; float matrix[4][4]; const char *lines[]; };",
+			&Record{Kind: "struct", Tag: "Buffers", Fields: []Field{
+				{Name: "name", Type: Type{Spelling: "char [16]", Base: "char", Array: []string{"16"}}},
+				{Name: "matrix", Type: Type{Spelling: "float [4][4]", Base: "float", Array: []string{"4", "4"}}},
+				{Name: "lines", Type: Type{Spelling: "const char *[]", Base: "char", Pointers: 1, Qualifiers: []string{"const"}, Array: []string{""}}},
+			}},
+		},
+		{
+			// anonymous and named nested structs and unions
+			"typedef union { struct { uint8_t r, g, b; }; struct Packed { uint32_t rgb; } packed; union { int i; float f; }; } Color;",
+			&Record{Kind: "union", Name: "Color", Fields: []Field{
+				{Type: Type{Spelling: "struct", Base: "struct"}, Fields: []Field{
+					{Name: "r", Type: Type{Spelling: "uint8_t", Base: "uint8_t"}},
+					{Name: "g", Type: Type{Spelling: "uint8_t", Base: "uint8_t"}},
+					{Name: "b", Type: Type{Spelling: "uint8_t", Base: "uint8_t"}},
+				}},
+				{Name: "packed", Type: Type{Spelling: "struct Packed", Base: "struct Packed"}, Fields: []Field{
+					{Name: "rgb", Type: Type{Spelling: "uint32_t", Base: "uint32_t"}},
+				}},
+				{Type: Type{Spelling: "union", Base: "union"}, Fields: []Field{
+					{Name: "i", Type: Type{Spelling: "int", Base: "int"}},
+					{Name: "f", Type: Type{Spelling: "float", Base: "float"}},
+				}},
+			}},
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseRecord(test.decl)
+		if err != nil {
+			t.Errorf("ParseRecord(%q): %v", test.decl, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseRecord(%q) =\n%+v\nwant\n%+v", test.decl, got, test.want)
+		}
+	}
+}
+
+func TestLinkFields(t *testing.T) {
+	r, err := ParseRecord("typedef struct { CGPoint origin; union { CGSize size; CGFloat length; }; int reserved; } Box;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := map[string]string{
+		"origin": "foundation/box/origin",
+		"size":   "foundation/box/size",
+		"length": "foundation/box/length",
+	}
+	LinkFields(r.Fields, func(name string) string { return pages[name] })
+
+	var got []string
+	var walk func(fields []Field)
+	walk = func(fields []Field) {
+		for _, f := range fields {
+			got = append(got, f.Name+"="+f.Path)
+			walk(f.Fields)
+		}
+	}
+	walk(r.Fields)
+	want := []string{"origin=foundation/box/origin", "=", "size=foundation/box/size", "length=foundation/box/length", "reserved="}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("linked fields %q, want %q", got, want)
+	}
+}
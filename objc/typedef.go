@@ -0,0 +1,194 @@
+package objc
+
+import "strings"
+
+// Typedef is a parsed typedef such as
+//
+//	typedef NSString *NSNotificationName NS_TYPED_EXTENSIBLE_ENUM;
+//
+// Type is the aliased type. For NS_ENUM and NS_OPTIONS typedefs it is
+// the underlying integer type.
+type Typedef struct {
+	Name       string
+	Type       Type
+	Attributes []string `json:",omitempty"`
+}
+
+// enum macros taking the underlying type and the name
+var enumMacros = map[string]bool{
+	"NS_ENUM": true, "NS_OPTIONS": true, "NS_CLOSED_ENUM": true, "NS_ERROR_ENUM": true,
+	"CF_ENUM": true, "CF_OPTIONS": true, "CF_CLOSED_ENUM": true,
+}
+
+// ParseTypedef parses a typedef declaration.
+func ParseTypedef(decl string) (*Typedef, error) {
+	p, err := newParser(decl)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("typedef"); err != nil {
+		return nil, err
+	}
+	td := &Typedef{}
+	if t := p.peek(); t.kind == tokIdent && enumMacros[t.text] && p.peekN(1).is("(") {
+		p.pos += 2
+		if t.text == "NS_ERROR_ENUM" {
+			// NS_ERROR_ENUM(domain, name) is an NSInteger enum
+			p.next()
+			td.Type = Type{Spelling: "NSInteger", Base: "NSInteger"}
+		} else if td.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokIdent {
+			return nil, p.errorf("expected enum name")
+		}
+		td.Name = p.next().text
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if p.peek().is("{") {
+			if err := p.skipBalanced(); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		specStart := p.pos
+		spec, err := p.parseSpecifiers()
+		if err != nil {
+			return nil, err
+		}
+		if td.Name, td.Type, err = p.parseDeclarator(spec, specStart, p.pos); err != nil {
+			return nil, err
+		}
+		if td.Name == "" {
+			return nil, p.errorf("expected typedef name")
+		}
+	}
+	if td.Attributes, err = p.parseAttributes(); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected token")
+	}
+	return td, nil
+}
+
+// Canonicalize follows the typedefs in t down to a type that is not a
+// typedef, using lookup to find the aliased type of a typedef name. It
+// returns each step of the chain, ending with the canonical type.
+func Canonicalize(t Type, lookup func(name string) (Type, bool)) (chain []Type, canonical Type) {
+	seen := map[string]bool{}
+	for !seen[t.Base] {
+		seen[t.Base] = true
+		alias, ok := lookup(t.Base)
+		if !ok {
+			break
+		}
+		if alias.Base == "" {
+			// a function or block type can't take on pointers or arrays
+			if t.Pointers > 0 || t.Array != nil {
+				break
+			}
+			t = alias
+		} else {
+			next := Type{
+				Base:       alias.Base,
+				Pointers:   alias.Pointers + t.Pointers,
+				Qualifiers: append(append([]string(nil), alias.Qualifiers...), t.Qualifiers...),
+				Array:      append(append([]string(nil), t.Array...), alias.Array...),
+				Args:       alias.Args,
+				Protocols:  alias.Protocols,
+			}
+			if next.Args == nil && next.Protocols == nil {
+				next.Args, next.Protocols = t.Args, t.Protocols
+			}
+			next.annotate()
+			next.Spelling = next.spell()
+			t = next
+		}
+		chain = append(chain, t)
+	}
+	return chain, t
+}
+
+// spell spells a type from its parts, with qualifiers other than
+// nullability leading.
+func (t Type) spell() string {
+	var words []string
+	for _, q := range t.Qualifiers {
+		if nullabilities[q] == "" {
+			words = append(words, q)
+		}
+	}
+	s := strings.Join(append(words, t.Base), " ")
+	var args []string
+	for _, arg := range t.Args {
+		args = append(args, arg.Spelling)
+	}
+	args = append(args, t.Protocols...)
+	if args != nil {
+		s += "<" + strings.Join(args, ", ") + ">"
+	}
+	if t.Pointers > 0 {
+		s += " " + strings.Repeat("*", t.Pointers)
+	} else if t.Array != nil {
+		s += " "
+	}
+	for _, n := range t.Array {
+		s += "[" + n + "]"
+	}
+	return s
+}
+
+// ParseEnum parses an enumeration declaration, NS_ENUM or plain C, into
+// a typedef of its underlying integer type. The underlying type of a C
+// enumeration without a fixed type is int. Name is empty for an
+// anonymous enumeration.
+func ParseEnum(decl string) (*Typedef, error) {
+	p, err := newParser(decl)
+	if err != nil {
+		return nil, err
+	}
+	typedef := p.accept("typedef")
+	if typedef && enumMacros[p.peek().text] {
+		return ParseTypedef(decl)
+	}
+	if err := p.expect("enum"); err != nil {
+		return nil, err
+	}
+	if err := p.skipAttributes(); err != nil {
+		return nil, err
+	}
+	td := &Typedef{Type: Type{Spelling: "int", Base: "int"}}
+	if p.peek().kind == tokIdent {
+		td.Name = p.next().text
+	}
+	if p.accept(":") {
+		if td.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek().is("{") {
+		if err := p.skipBalanced(); err != nil {
+			return nil, err
+		}
+	}
+	if typedef && p.peek().kind == tokIdent {
+		td.Name = p.next().text
+	}
+	if td.Attributes, err = p.parseAttributes(); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected token")
+	}
+	if typedef && td.Name == "" {
+		return nil, p.errorf("expected enum name")
+	}
+	return td, nil
+}
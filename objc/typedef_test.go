@@ -0,0 +1,198 @@
+package objc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTypedef(t *testing.T) {
+	tests := []struct {
+		decl string
+		want *Typedef
+	}{
+		{
+			"typedef long NSInteger;",
+			&Typedef{Name: "NSInteger", Type: Type{Spelling: "long", Base: "long"}},
+		},
+		{
+			"typedef NSString *NSNotificationName NS_TYPED_EXTENSIBLE_ENUM;",
+			&Typedef{
+				Name:       "NSNotificationName",
+				Type:       Type{Spelling: "NSString *", Base: "NSString", Pointers: 1},
+				Attributes: []string{"NS_TYPED_EXTENSIBLE_ENUM"},
+			},
+		},
+		{
+			"typedef NS_ENUM(NSInteger, NSModalResponse) { NSModalResponseOK = 1 };",
+			&Typedef{Name: "NSModalResponse", Type: Type{Spelling: "NSInteger", Base: "NSInteger"}},
+		},
+		{
+			"typedef NS_ERROR_ENUM(NSCocoaErrorDomain, NSCocoaError) { NSFileNoSuchFileError = 4 };",
+			&Typedef{Name: "NSCocoaError", Type: Type{Spelling: "NSInteger", Base: "NSInteger"}},
+		},
+		{
+			"typedef unsigned char uuid_t[16];",
+			&Typedef{Name: "uuid_t", Type: Type{Spelling: "unsigned char [16]", Base: "unsigned char", Array: []string{"16"}}},
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseTypedef(test.decl)
+		if err != nil {
+			t.Errorf("ParseTypedef(%q): %v", test.decl, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseTypedef(%q) =\n%+v\nwant\n%+v", test.decl, got, test.want)
+		}
+	}
+}
+
+func TestParseEnum(t *testing.T) {
+	tests := []struct {
+		decl string
+		want *Typedef
+	}{
+		{
+			"typedef NS_OPTIONS(NSUInteger, NSWindowStyleMask) { NSWindowStyleMaskBorderless = 0 };",
+			&Typedef{Name: "NSWindowStyleMask", Type: Type{Spelling: "NSUInteger", Base: "NSUInteger"}},
+		},
+		{
+			"enum CGLineCap { kCGLineCapButt, kCGLineCapRound };",
+			&Typedef{Name: "CGLineCap", Type: Type{Spelling: "int", Base: "int"}},
+		},
+		{
+			"typedef enum : uint8_t { A, B } Small;",
+			&Typedef{Name: "Small", Type: Type{Spelling: "uint8_t", Base: "uint8_t"}},
+		},
+		// anonymous enumerations name no type
+		{
+			"enum { kFoo = 1 };",
+			&Typedef{Type: Type{Spelling: "int", Base: "int"}},
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseEnum(test.decl)
+		if err != nil {
+			t.Errorf("ParseEnum(%q): %v", test.decl, err)
+			continue
+		}
+		got.Attributes = nil
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseEnum(%q) =\n%+v\nwant\n%+v", test.decl, got, test.want)
+		}
+	}
+	if td, err := ParseEnum("typedef enum { A };"); err == nil {
+		t.Errorf("ParseEnum of an unnamed typedef = %+v, want an error", td)
+	}
+}
+
+func TestParseConstantAnonymousEnum(t *testing.T) {
+	tests := []struct {
+		decl string
+		want *Constant
+	}{
+		{"enum { kFoo = 1 };", &Constant{Name: "kFoo", Type: &Type{Spelling: "int", Base: "int"}, Expr: "1"}},
+		{"enum : NSUInteger { kBar = 1UL << 3 };", &Constant{Name: "kBar", Type: &Type{Spelling: "NSUInteger", Base: "NSUInteger"}, Expr: "1UL << 3"}},
+		{"enum { kBaz };", &Constant{Name: "kBaz", Type: &Type{Spelling: "int", Base: "int"}}},
+	}
+	for _, test := range tests {
+		got, err := ParseConstant(test.decl)
+		if err != nil {
+			t.Errorf("ParseConstant(%q): %v", test.decl, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseConstant(%q) = %+v, want %+v", test.decl, got, test.want)
+		}
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	typedefs := map[string]string{
+		"NSInteger":       "typedef long NSInteger;",
+		"NSModalResponse": "typedef NSInteger NSModalResponse;",
+		"CFStringRef":     "typedef const struct __CFString *CFStringRef;",
+		"StringList":      "typedef NSArray<NSString *> * _Nullable StringList;",
+		"Delegate":        "typedef id<NSWindowDelegate> Delegate;",
+		"AnyView":         "typedef __kindof NSView *AnyView;",
+		"Handler":         "typedef void (^Handler)(NSModalResponse returnCode);",
+		"uuid_t":          "typedef unsigned char uuid_t[16];",
+		"Loop":            "typedef Loop2 Loop;",
+		"Loop2":           "typedef Loop Loop2;",
+	}
+	lookup := func(name string) (Type, bool) {
+		decl, ok := typedefs[name]
+		if !ok {
+			return Type{}, false
+		}
+		td, err := ParseTypedef(decl)
+		if err != nil {
+			t.Fatalf("ParseTypedef(%q): %v", decl, err)
+		}
+		return td.Type, true
+	}
+	parse := func(spelling string) Type {
+		p, err := newParser(spelling)
+		if err != nil {
+			t.Fatal(err)
+		}
+		typ, err := p.parseType()
+		if err != nil {
+			t.Fatalf("parseType(%q): %v", spelling, err)
+		}
+		return typ
+	}
+	tests := []struct {
+		spelling string
+		chain    []string
+		want     Type
+	}{
+		{"NSModalResponse", []string{"NSInteger", "long"}, Type{Spelling: "long", Base: "long"}},
+		{"NSInteger *", []string{"long *"}, Type{Spelling: "long *", Base: "long", Pointers: 1}},
+		{"CFStringRef", []string{"const struct __CFString *"}, Type{
+			Spelling: "const struct __CFString *", Base: "struct __CFString", Pointers: 1, Qualifiers: []string{"const"},
+		}},
+		// type arguments, protocols and annotations carry through
+		{"StringList", []string{"NSArray<NSString *> *"}, Type{
+			Spelling:    "NSArray<NSString *> *",
+			Base:        "NSArray",
+			Pointers:    1,
+			Qualifiers:  []string{"_Nullable"},
+			Nullability: "nullable",
+			Args:        []Type{{Spelling: "NSString *", Base: "NSString", Pointers: 1}},
+		}},
+		{"Delegate _Nonnull", []string{"id<NSWindowDelegate>"}, Type{
+			Spelling:    "id<NSWindowDelegate>",
+			Base:        "id",
+			Qualifiers:  []string{"_Nonnull"},
+			Nullability: "nonnull",
+			Protocols:   []string{"NSWindowDelegate"},
+		}},
+		{"AnyView", []string{"__kindof NSView *"}, Type{
+			Spelling: "__kindof NSView *", Base: "NSView", Pointers: 1, Qualifiers: []string{"__kindof"}, KindOf: true,
+		}},
+		{"uuid_t", []string{"unsigned char [16]"}, Type{Spelling: "unsigned char [16]", Base: "unsigned char", Array: []string{"16"}}},
+		{"NSString *", nil, Type{Spelling: "NSString *", Base: "NSString", Pointers: 1}},
+		// cycles end
+		{"Loop", []string{"Loop2", "Loop"}, Type{Spelling: "Loop", Base: "Loop"}},
+	}
+	for _, test := range tests {
+		chain, got := Canonicalize(parse(test.spelling), lookup)
+		var spellings []string
+		for _, t := range chain {
+			spellings = append(spellings, t.Spelling)
+		}
+		if !reflect.DeepEqual(spellings, test.chain) {
+			t.Errorf("Canonicalize(%q) chain %q, want %q", test.spelling, spellings, test.chain)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Canonicalize(%q) =\n%+v\nwant\n%+v", test.spelling, got, test.want)
+		}
+	}
+
+	// a block typedef is its signature
+	_, got := Canonicalize(parse("Handler"), lookup)
+	if got.Func == nil || !got.Func.Block || len(got.Func.Params) != 1 {
+		t.Errorf("Canonicalize(Handler) = %+v, want the block type", got)
+	}
+}
@@ -0,0 +1,265 @@
+package objc
+
+import "strings"
+
+// Type is a C or Objective-C type as spelled in a declaration.
+//
+// Base is the named type with its tag for struct, union and enum types
+// ("NSString", "unsigned long", "struct CGPoint"). Pointers counts the
+// levels of indirection applied to Base, and Array the dimensions of an
+// array of those. Block and function pointer types have Func instead of
+// Base. Otherwise Base is empty when the type could not be understood,
+// leaving only Spelling.
+type Type struct {
+	Spelling   string
+	Base       string    `json:",omitempty"`
+	Pointers   int       `json:",omitempty"`
+	Qualifiers []string  `json:",omitempty"` // const, volatile, __kindof, oneway, ...
+	Array      []string  `json:",omitempty"` // array lengths, outermost first, "" if unspecified
+	Func       *FuncType `json:",omitempty"` // block or function pointer
+
+	Nullability string `json:",omitempty"` // nullable, nonnull, null_unspecified or null_resettable
+	KindOf      bool   `json:",omitempty"` // __kindof
+
+	// Args are the type arguments of a generic class, NSString * of
+	// NSArray<NSString *> *. The protocols qualifying id and Class are
+	// Protocols instead. Other classes take protocols in the same
+	// syntax, so a class that is not generic has its protocols in Args.
+	Args      []Type   `json:",omitempty"`
+	Protocols []string `json:",omitempty"` // NSCopying of id<NSCopying>
+}
+
+// FuncType is the signature of a block or function pointer type such as
+//
+//	void (^)(NSModalResponse returnCode)
+//
+// Escaping is false for blocks marked NS_NOESCAPE. Nullability is that of
+// the block or function pointer itself.
+type FuncType struct {
+	Return      Type
+	Params      []Param `json:",omitempty"`
+	Variadic    bool    `json:",omitempty"`
+	Block       bool    `json:",omitempty"` // ^ rather than *
+	Escaping    bool    `json:",omitempty"`
+	Nullability string  `json:",omitempty"`
+}
+
+func (t Type) String() string {
+	return t.Spelling
+}
+
+// qualifiers that may appear anywhere among the specifiers of a type or
+// after a pointer
+var qualifierWords = map[string]bool{
+	"const": true, "volatile": true, "restrict": true, "__restrict": true,
+	"_Atomic": true, "__kindof": true,
+	"nullable": true, "nonnull": true, "null_unspecified": true, "null_resettable": true,
+	"_Nullable": true, "_Nonnull": true, "_Null_unspecified": true, "_Nullable_result": true,
+	"__nullable": true, "__nonnull": true, "__null_unspecified": true,
+	"__strong": true, "__weak": true, "__unsafe_unretained": true, "__autoreleasing": true,
+	"__block": true, "NS_NOESCAPE": true, "CF_NOESCAPE": true,
+	// ownership annotations of returns and parameters
+	"NS_RETURNS_RETAINED": true, "CF_RETURNS_RETAINED": true,
+	"NS_RETURNS_NOT_RETAINED": true, "CF_RETURNS_NOT_RETAINED": true,
+	"NS_CONSUMED": true, "CF_CONSUMED": true, "NS_RELEASES_ARGUMENT": true, "CF_RELEASES_ARGUMENT": true,
+}
+
+// Objective-C type qualifiers, only leading the parenthesized types of a
+// method's return and arguments. Elsewhere they are plain names.
+var methodQualifiers = map[string]bool{
+	"oneway": true, "in": true, "out": true, "inout": true, "bycopy": true, "byref": true,
+}
+
+// words making up builtin arithmetic types
+var builtinWords = map[string]bool{
+	"void": true, "char": true, "short": true, "int": true, "long": true,
+	"float": true, "double": true, "signed": true, "unsigned": true,
+	"_Bool": true, "bool": true, "_Complex": true, "__int128": true,
+}
+
+// annotation macros that decorate a type without being part of it
+var typeAnnotations = map[string]bool{
+	"NS_VALID_UNTIL_END_OF_SCOPE": true, "__unused": true,
+}
+
+// parseTypeUntil parses the type spelled by the tokens up to the closing
+// bracket matching an already consumed opening bracket, and consumes the
+// closing bracket. A type that is not fully understood is returned with
+// only its Spelling.
+func (p *parser) parseTypeUntil(close string) (Type, error) {
+	start := p.pos
+	end, err := p.matching(close)
+	if err != nil {
+		return Type{}, err
+	}
+	sub := &parser{src: p.src, toks: p.toks[start:end]}
+	t, err := sub.parseType()
+	if err != nil || !sub.atEnd() {
+		t = Type{}
+	}
+	t.Spelling = p.spelling(start, end)
+	p.pos = end + 1
+	return t, nil
+}
+
+// parseMethodType parses the parenthesized return or argument type of a
+// method, after the opening parenthesis, and the closing parenthesis.
+func (p *parser) parseMethodType() (Type, error) {
+	start := p.pos
+	var qs []string
+	for p.peek().kind == tokIdent && methodQualifiers[p.peek().text] {
+		qs = append(qs, p.next().text)
+	}
+	t, err := p.parseTypeUntil(")")
+	if err != nil || qs == nil {
+		return t, err
+	}
+	t.Qualifiers = append(qs, t.Qualifiers...)
+	t.Spelling = p.spelling(start, p.pos-1)
+	return t, nil
+}
+
+// matching returns the index of the token closing the bracket before the
+// current position.
+func (p *parser) matching(close string) (int, error) {
+	open := map[string]string{")": "(", "]": "[", "}": "{", ">": "<"}[close]
+	depth := 1
+	for i := p.pos; i < len(p.toks); i++ {
+		switch p.toks[i].text {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, p.errorf("expected %q", close)
+}
+
+// parseType parses declaration specifiers followed by an abstract
+// declarator, with no declared name.
+func (p *parser) parseType() (Type, error) {
+	start := p.pos
+	spec, err := p.parseSpecifiers()
+	if err != nil {
+		return spec, err
+	}
+	name, t, err := p.parseDeclarator(spec, start, p.pos)
+	if err != nil {
+		return t, err
+	}
+	if name != "" {
+		return t, p.errorf("unexpected name %s", name)
+	}
+	return t, nil
+}
+
+// parseSpecifiers parses qualifiers and the base type of a declaration.
+func (p *parser) parseSpecifiers() (Type, error) {
+	var t Type
+	var words []string
+	for {
+		tok := p.peek()
+		if tok.kind != tokIdent {
+			break
+		}
+		switch {
+		case qualifierWords[tok.text]:
+			t.Qualifiers = append(t.Qualifiers, tok.text)
+			p.next()
+		case typeAnnotations[tok.text]:
+			p.next()
+		case tok.text == "__attribute__" || tok.text == "__attribute":
+			p.next()
+			if p.peek().is("(") {
+				if err := p.skipBalanced(); err != nil {
+					return t, err
+				}
+			}
+		case builtinWords[tok.text] && t.Base == "":
+			words = append(words, tok.text)
+			p.next()
+		case (tok.text == "struct" || tok.text == "union" || tok.text == "enum") && t.Base == "" && words == nil:
+			p.next()
+			t.Base = tok.text
+			if name := p.peek(); name.kind == tokIdent {
+				t.Base += " " + name.text
+				p.next()
+			}
+			if p.peek().is("{") {
+				if err := p.skipBalanced(); err != nil {
+					return t, err
+				}
+			}
+		case t.Base == "" && words == nil:
+			t.Base = tok.text
+			p.next()
+			if p.accept("<") {
+				if err := p.parseTypeArgs(&t); err != nil {
+					return t, err
+				}
+			}
+		default:
+			// a declared name follows the type
+			return t.withWords(words), p.checkBase(t, words)
+		}
+	}
+	return t.withWords(words), p.checkBase(t, words)
+}
+
+// parseTypeArgs parses type arguments or protocols following an opening
+// angle bracket, and the closing bracket.
+func (p *parser) parseTypeArgs(t *Type) error {
+	var args []Type
+	for {
+		arg, err := p.parseType()
+		if err != nil {
+			return err
+		}
+		args = append(args, arg)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(">"); err != nil {
+		return err
+	}
+	if t.Base == "id" || t.Base == "Class" {
+		for _, arg := range args {
+			t.Protocols = append(t.Protocols, arg.Base)
+		}
+	} else {
+		t.Args = args
+	}
+	return nil
+}
+
+func (t Type) withWords(words []string) Type {
+	if words != nil {
+		t.Base = strings.Join(words, " ")
+		if t.Base == "signed" || t.Base == "unsigned" {
+			t.Base += " int"
+		}
+	}
+	return t
+}
+
+func (p *parser) checkBase(t Type, words []string) error {
+	if t.Base == "" && words == nil {
+		return p.errorf("expected type")
+	}
+	return nil
+}
+
+// parsePointers parses pointer declarators and the qualifiers applied to
+// each pointer.
+func (p *parser) parsePointers(t *Type) {
+	for p.accept("*") {
+		t.Pointers++
+		for p.peek().kind == tokIdent && qualifierWords[p.peek().text] {
+			t.Qualifiers = append(t.Qualifiers, p.next().text)
+		}
+	}
+}
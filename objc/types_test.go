@@ -0,0 +1,85 @@
+package objc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		spelling string
+		want     Type
+	}{
+		{"unsigned long", Type{Base: "unsigned long"}},
+		{"const char *", Type{Base: "char", Pointers: 1, Qualifiers: []string{"const"}}},
+		{"struct CGPoint", Type{Base: "struct CGPoint"}},
+		{"NSArray<NSString *> *", Type{
+			Base:     "NSArray",
+			Pointers: 1,
+			Args:     []Type{{Spelling: "NSString *", Base: "NSString", Pointers: 1}},
+		}},
+		{"NSDictionary<NSString *, NSArray<NSNumber *> *> *", Type{
+			Base:     "NSDictionary",
+			Pointers: 1,
+			Args: []Type{
+				{Spelling: "NSString *", Base: "NSString", Pointers: 1},
+				{Spelling: "NSArray<NSNumber *> *", Base: "NSArray", Pointers: 1, Args: []Type{
+					{Spelling: "NSNumber *", Base: "NSNumber", Pointers: 1},
+				}},
+			},
+		}},
+		{"id<NSCopying, NSCoding>", Type{Base: "id", Protocols: []string{"NSCopying", "NSCoding"}}},
+		{"__kindof NSArray<__kindof NSView *> *", Type{
+			Base:       "NSArray",
+			Pointers:   1,
+			Qualifiers: []string{"__kindof"},
+			KindOf:     true,
+			Args:       []Type{{Spelling: "__kindof NSView *", Base: "NSView", Pointers: 1, Qualifiers: []string{"__kindof"}, KindOf: true}},
+		}},
+		{"void (^)(NSModalResponse returnCode)", Type{
+			Func: &FuncType{
+				Return:   Type{Spelling: "void", Base: "void"},
+				Params:   []Param{{Name: "returnCode", Type: Type{Spelling: "NSModalResponse", Base: "NSModalResponse"}}},
+				Block:    true,
+				Escaping: true,
+			},
+		}},
+		{"void (^ _Nullable)(void)", Type{
+			Func: &FuncType{
+				Return:      Type{Spelling: "void", Base: "void"},
+				Block:       true,
+				Escaping:    true,
+				Nullability: "nullable",
+			},
+		}},
+		{"BOOL (^)(id, ...)", Type{
+			Func: &FuncType{
+				Return:   Type{Spelling: "BOOL", Base: "BOOL"},
+				Params:   []Param{{Type: Type{Spelling: "id", Base: "id"}}},
+				Variadic: true,
+				Block:    true,
+				Escaping: true,
+			},
+		}},
+		{"int *(*)(void)", Type{
+			Func: &FuncType{Return: Type{Spelling: "int *", Base: "int", Pointers: 1}},
+		}},
+		// in and out are only qualifiers in method types
+		{"in", Type{Base: "in"}},
+	}
+	for _, test := range tests {
+		p, err := newParser(test.spelling)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := p.parseType()
+		if err != nil {
+			t.Errorf("parseType(%q): %v", test.spelling, err)
+			continue
+		}
+		got.Spelling = ""
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseType(%q) =\n%+v\nwant\n%+v", test.spelling, got, test.want)
+		}
+	}
+}
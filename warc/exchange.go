@@ -0,0 +1,229 @@
+package warc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotArchived is returned by Archive.Get for URIs with no response.
+var ErrNotArchived = errors.New("warc: not in archive")
+
+// Exchange is an HTTP request and its response.
+type Exchange struct {
+	URI            string
+	Date           time.Time
+	RequestHeader  http.Header
+	Status         int
+	ResponseHeader http.Header
+	Body           []byte
+}
+
+// WriteInfo writes a warcinfo record describing the file. It is
+// customary as the first record.
+func (w *Writer) WriteInfo(fields map[string]string) error {
+	var buf bytes.Buffer
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, fields[k])
+	}
+	return w.WriteRecord(&Record{
+		Header: Header{
+			{"WARC-Type", "warcinfo"},
+			{"Content-Type", "application/warc-fields"},
+		},
+		Content: buf.Bytes(),
+	})
+}
+
+// WriteExchange writes x as a request record followed by its response
+// record.
+func (w *Writer) WriteExchange(x *Exchange) error {
+	u, err := url.Parse(x.URI)
+	if err != nil {
+		return err
+	}
+	date := x.Date.UTC().Format(time.RFC3339)
+
+	var req bytes.Buffer
+	fmt.Fprintf(&req, "GET %s HTTP/1.1\r\nHost: %s\r\n", u.RequestURI(), u.Host)
+	if err := x.RequestHeader.Write(&req); err != nil {
+		return err
+	}
+	req.WriteString("\r\n")
+
+	var resp bytes.Buffer
+	fmt.Fprintf(&resp, "HTTP/1.1 %d %s\r\n", x.Status, http.StatusText(x.Status))
+	h := x.ResponseHeader.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	// the body is archived decoded, whatever it was on the wire
+	h.Del("Content-Encoding")
+	h.Del("Transfer-Encoding")
+	h.Set("Content-Length", fmt.Sprint(len(x.Body)))
+	if err := h.Write(&resp); err != nil {
+		return err
+	}
+	resp.WriteString("\r\n")
+	resp.Write(x.Body)
+
+	respID, err := newRecordID()
+	if err != nil {
+		return err
+	}
+	if err := w.WriteRecord(&Record{
+		Header: Header{
+			{"WARC-Type", "request"},
+			{"WARC-Date", date},
+			{"WARC-Target-URI", x.URI},
+			{"WARC-Concurrent-To", respID},
+			{"Content-Type", "application/http;msgtype=request"},
+		},
+		Content: req.Bytes(),
+	}); err != nil {
+		return err
+	}
+	return w.WriteRecord(&Record{
+		Header: Header{
+			{"WARC-Type", "response"},
+			{"WARC-Record-ID", respID},
+			{"WARC-Date", date},
+			{"WARC-Target-URI", x.URI},
+			{"Content-Type", "application/http;msgtype=response"},
+		},
+		Content: resp.Bytes(),
+	})
+}
+
+// ParseResponse decodes a response record into an Exchange.
+func ParseResponse(r *Record) (*Exchange, error) {
+	if r.Type() != "response" {
+		return nil, fmt.Errorf("warc: %s record is not a response", r.Type())
+	}
+	date, err := time.Parse(time.RFC3339, r.Header.Get("WARC-Date"))
+	if err != nil {
+		return nil, fmt.Errorf("warc: bad WARC-Date: %w", err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(r.Content)), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Exchange{
+		URI:            r.Header.Get("WARC-Target-URI"),
+		Date:           date,
+		Status:         resp.StatusCode,
+		ResponseHeader: resp.Header,
+		Body:           body,
+	}, nil
+}
+
+// Archive serves archived responses by URI for replay. Only the offsets
+// of records are kept in memory.
+type Archive struct {
+	f       *os.File
+	offsets map[string]int64
+}
+
+// OpenArchive opens and indexes the WARC file at filename. If a URI was
+// archived more than once, the last successful response wins, or the
+// last failing one if none succeeded; 304 responses are skipped since
+// replay has no earlier content to revalidate.
+func OpenArchive(filename string) (*Archive, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	rd, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	a := &Archive{f: f, offsets: make(map[string]int64)}
+	succeeded := make(map[string]bool)
+	for {
+		rec, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		if rec.Type() != "response" {
+			continue
+		}
+		status := responseStatus(rec.Content)
+		if status == http.StatusNotModified {
+			continue
+		}
+		uri := rec.Header.Get("WARC-Target-URI")
+		ok := status >= 200 && status < 300
+		if succeeded[uri] && !ok {
+			continue
+		}
+		succeeded[uri] = ok
+		a.offsets[uri] = rd.Offset()
+	}
+	return a, nil
+}
+
+// Len returns the number of archived URIs.
+func (a *Archive) Len() int {
+	return len(a.offsets)
+}
+
+// Get returns the archived response for uri.
+func (a *Archive) Get(uri string) (*Exchange, error) {
+	off, ok := a.offsets[uri]
+	if !ok {
+		return nil, ErrNotArchived
+	}
+	if _, err := a.f.Seek(off, io.SeekStart); err != nil {
+		return nil, err
+	}
+	rd, err := NewReader(a.f)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := rd.Next()
+	if err != nil {
+		return nil, err
+	}
+	return ParseResponse(rec)
+}
+
+func (a *Archive) Close() error {
+	return a.f.Close()
+}
+
+// responseStatus returns the status code of an HTTP response message,
+// or 0 if it has none.
+func responseStatus(content []byte) int {
+	line, _, _ := bytes.Cut(content, []byte("\r\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) < 2 {
+		return 0
+	}
+	status, _ := strconv.Atoi(fields[1])
+	return status
+}
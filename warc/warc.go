@@ -0,0 +1,283 @@
+// Package warc reads and writes WARC 1.1 files (ISO 28500), used to
+// archive the HTTP exchanges behind the store so a fetch can be replayed
+// without network access.
+//
+// Files ending in ".gz" are written with every record in its own gzip
+// member, as is conventional, so records can be read back individually
+// by offset.
+package warc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const version = "WARC/1.1"
+
+// Field is a single WARC header field.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Header is a WARC record header. Field order is preserved.
+type Header []Field
+
+// Get returns the value of the first field with name, compared
+// case-insensitively.
+func (h Header) Get(name string) string {
+	for _, f := range h {
+		if strings.EqualFold(f.Name, name) {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Set replaces the value of the field with name, or appends it.
+func (h *Header) Set(name, value string) {
+	for i, f := range *h {
+		if strings.EqualFold(f.Name, name) {
+			(*h)[i].Value = value
+			return
+		}
+	}
+	*h = append(*h, Field{Name: name, Value: value})
+}
+
+// Record is a WARC record. Content-Length is derived from Content when
+// writing.
+type Record struct {
+	Header  Header
+	Content []byte
+}
+
+// Type returns the WARC-Type of the record.
+func (r *Record) Type() string {
+	return r.Header.Get("WARC-Type")
+}
+
+// Writer writes WARC records.
+type Writer struct {
+	w        io.Writer
+	compress bool
+}
+
+// NewWriter returns a Writer writing to w, gzipping each record as its
+// own member if compress is set.
+func NewWriter(w io.Writer, compress bool) *Writer {
+	return &Writer{w: w, compress: compress}
+}
+
+// WriteRecord writes r, filling in WARC-Record-ID and WARC-Date if they
+// are not set.
+func (w *Writer) WriteRecord(r *Record) error {
+	if r.Header.Get("WARC-Record-ID") == "" {
+		id, err := newRecordID()
+		if err != nil {
+			return err
+		}
+		r.Header.Set("WARC-Record-ID", id)
+	}
+	if r.Header.Get("WARC-Date") == "" {
+		r.Header.Set("WARC-Date", time.Now().UTC().Format(time.RFC3339))
+	}
+	r.Header.Set("Content-Length", strconv.Itoa(len(r.Content)))
+
+	var buf bytes.Buffer
+	buf.WriteString(version + "\r\n")
+	for _, f := range r.Header {
+		fmt.Fprintf(&buf, "%s: %s\r\n", f.Name, f.Value)
+	}
+	buf.WriteString("\r\n")
+	buf.Write(r.Content)
+	buf.WriteString("\r\n\r\n")
+
+	if !w.compress {
+		_, err := w.w.Write(buf.Bytes())
+		return err
+	}
+	zw := gzip.NewWriter(w.w)
+	if _, err := zw.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Reader reads WARC records sequentially.
+type Reader struct {
+	cr       *countingReader
+	compress bool
+	zr       *gzip.Reader
+	offset   int64
+}
+
+// NewReader returns a Reader for r, detecting gzip compression.
+func NewReader(r io.Reader) (*Reader, error) {
+	cr := &countingReader{r: bufio.NewReader(r)}
+	magic, err := cr.r.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return &Reader{
+		cr:       cr,
+		compress: len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b,
+	}, nil
+}
+
+// Offset returns the position in the underlying reader where the record
+// last returned by Next starts.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Next returns the next record, or io.EOF at the end of the file.
+func (r *Reader) Next() (*Record, error) {
+	r.offset = r.cr.n
+	if !r.compress {
+		return readRecord(r.cr)
+	}
+
+	if _, err := r.cr.r.Peek(1); err == io.EOF {
+		return nil, io.EOF
+	}
+	if r.zr == nil {
+		zr, err := gzip.NewReader(r.cr)
+		if err != nil {
+			return nil, err
+		}
+		r.zr = zr
+	} else if err := r.zr.Reset(r.cr); err != nil {
+		return nil, err
+	}
+	r.zr.Multistream(false)
+	rec, err := readRecord(bufio.NewReader(r.zr))
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	// consume the rest of the member so the offset lands on the next one
+	if _, err := io.Copy(io.Discard, r.zr); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+func readRecord(r byteReader) (*Record, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	// tolerate stray blank lines between records
+	for line == "" {
+		if line, err = readLine(r); err != nil {
+			return nil, err
+		}
+	}
+	if !strings.HasPrefix(line, "WARC/") {
+		return nil, fmt.Errorf("warc: bad version line %q", line)
+	}
+
+	rec := &Record{}
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, unexpected(err)
+		}
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("warc: bad header line %q", line)
+		}
+		rec.Header = append(rec.Header, Field{Name: name, Value: strings.TrimSpace(value)})
+	}
+
+	n, err := strconv.Atoi(rec.Header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("warc: bad Content-Length: %w", err)
+	}
+	rec.Content = make([]byte, n)
+	if _, err := io.ReadFull(r, rec.Content); err != nil {
+		return nil, unexpected(err)
+	}
+	var trailer [4]byte
+	if _, err := io.ReadFull(r, trailer[:]); err != nil {
+		return nil, unexpected(err)
+	}
+	if string(trailer[:]) != "\r\n\r\n" {
+		return nil, errors.New("warc: missing record trailer")
+	}
+	return rec, nil
+}
+
+func readLine(r io.ByteReader) (string, error) {
+	var b []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(b) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		if c == '\n' {
+			return strings.TrimSuffix(string(b), "\r"), nil
+		}
+		b = append(b, c)
+	}
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// countingReader counts the bytes consumed from r. It implements
+// io.ByteReader so gzip will not read past the end of a member.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+func newRecordID() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+}
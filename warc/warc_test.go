@@ -0,0 +1,115 @@
+package warc
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	page := "https://developer.apple.com/tutorials/data/documentation/appkit/nswindow.json?language=objc"
+	other := "https://developer.apple.com/tutorials/data/documentation/appkit/nsview.json?language=objc"
+	failing := "https://developer.apple.com/tutorials/data/documentation/appkit/nspanel.json?language=objc"
+	exchanges := []*Exchange{
+		{URI: page, Date: date, Status: 200, Body: []byte(`{"old":true}`),
+			ResponseHeader: http.Header{"Etag": {`"1"`}}},
+		{URI: other, Date: date, Status: 200, Body: []byte("binary\x00\r\n\r\nbody"),
+			ResponseHeader: http.Header{"Content-Encoding": {"gzip"}}},
+		{URI: page, Date: date.Add(time.Hour), Status: 200, Body: []byte(`{"new":true}`),
+			RequestHeader: http.Header{"If-None-Match": {`"1"`}}, ResponseHeader: http.Header{"Etag": {`"2"`}}},
+		// a revalidation doesn't replace the content, nor does a failure
+		{URI: page, Date: date.Add(2 * time.Hour), Status: 304,
+			RequestHeader: http.Header{"If-None-Match": {`"2"`}}},
+		{URI: page, Date: date.Add(3 * time.Hour), Status: 503},
+		// unless nothing succeeded
+		{URI: failing, Date: date, Status: 500},
+		{URI: failing, Date: date, Status: 503},
+	}
+
+	for _, name := range []string{"x.warc", "x.warc.gz"} {
+		filename := filepath.Join(t.TempDir(), name)
+		f, err := os.Create(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := NewWriter(f, filepath.Ext(name) == ".gz")
+		if err := w.WriteInfo(map[string]string{"software": "test"}); err != nil {
+			t.Fatal(err)
+		}
+		for _, x := range exchanges {
+			if x.RequestHeader == nil {
+				x.RequestHeader = http.Header{}
+			}
+			if err := w.WriteExchange(x); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// records come back in order
+		f, err = os.Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rd, err := NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var types []string
+		for {
+			rec, err := rd.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			types = append(types, rec.Type())
+		}
+		f.Close()
+		if want := "warcinfo" + strings.Repeat(" request response", len(exchanges)); strings.Join(types, " ") != want {
+			t.Errorf("%s: record types %q, want %q", name, strings.Join(types, " "), want)
+		}
+
+		a, err := OpenArchive(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Len() != 3 {
+			t.Errorf("%s: Len() = %d, want 3", name, a.Len())
+		}
+		x, err := a.Get(page)
+		if err != nil {
+			t.Fatalf("%s: Get(%s): %v", name, page, err)
+		}
+		if string(x.Body) != `{"new":true}` || x.Status != 200 || x.ResponseHeader.Get("ETag") != `"2"` || !x.Date.Equal(date.Add(time.Hour)) {
+			t.Errorf("%s: Get(%s) = %+v, want the second 200", name, page, x)
+		}
+		x, err = a.Get(other)
+		if err != nil {
+			t.Fatalf("%s: Get(%s): %v", name, other, err)
+		}
+		if !bytes.Equal(x.Body, exchanges[1].Body) || x.ResponseHeader.Get("Content-Encoding") != "" {
+			t.Errorf("%s: Get(%s) = %+v, want the body as written, decoded", name, other, x)
+		}
+		x, err = a.Get(failing)
+		if err != nil {
+			t.Fatalf("%s: Get(%s): %v", name, failing, err)
+		}
+		if x.Status != 503 {
+			t.Errorf("%s: Get(%s).Status = %d, want the last failure", name, failing, x.Status)
+		}
+		if _, err := a.Get(page + "&other"); err != ErrNotArchived {
+			t.Errorf("%s: Get of a missing URI: %v, want ErrNotArchived", name, err)
+		}
+		a.Close()
+	}
+}